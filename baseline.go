@@ -0,0 +1,76 @@
+package migo
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Baseline marks all loaded migrations up to and including `version`
+// as applied without running them, so migo can be introduced
+// on a database that already has their schema. `version` can be given
+// with or without name, e.g. `3.2.0`.
+func (m *Migrate) Baseline(version string) error {
+	baseline, err := versionWithOptionalName(version)
+	if err != nil {
+		return err
+	}
+
+	err = m.loadMigrations()
+	if err != nil {
+		return errors.New("can't load migrations " + err.Error())
+	}
+
+	verStrs, err := m.c.LoadVersions()
+	if err != nil {
+		return err
+	}
+
+	appliedVers, err := StringsToVersions(verStrs)
+	if err != nil {
+		return err
+	}
+
+	applied := map[string]bool{}
+	for _, v := range appliedVers {
+		applied[v.StringWithoutName()] = true
+	}
+
+	found := false
+	migrationsToRecord := []Migration{}
+	for _, migration := range m.sort(m.migrations, true) {
+		v := migration.Version()
+		if v.GreaterThan(baseline) {
+			break
+		}
+
+		if v.StringWithoutName() == baseline.StringWithoutName() {
+			found = true
+		}
+
+		if !applied[v.StringWithoutName()] {
+			migrationsToRecord = append(migrationsToRecord, migration)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("migration with version '%s' not found", baseline.StringWithoutName())
+	}
+
+	fmt.Fprintf(m.output(), "Going to baseline %d migration(s)...\n", len(migrationsToRecord))
+
+	for _, migration := range migrationsToRecord {
+		start := time.Now()
+		err := m.recordVersion(migration, 0)
+		err = m.recordHistory(migration.Version().String(), HistoryBaseline, start, err)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(m.output(), "Marked '%s' as applied.\n", migration.Version())
+	}
+
+	fmt.Fprintf(m.output(), "Database baselined at '%s'!\n", baseline.StringWithoutName())
+
+	return nil
+}
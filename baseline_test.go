@@ -0,0 +1,51 @@
+package migo
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestBaseline(t *testing.T) {
+	ioutil.WriteFile("3.0.0-add-phone.sql", []byte("-- migo:up\nUP SQL 3;\n-- migo:down\nDOWN SQL 3;\n"), 0644)
+	defer removeFiles("3.0.0-add-phone.sql")
+
+	c := &HistoryConnectionMock{}
+	m := newImportMigrate(c, "1.0.0-create-users", "2.1.0-add-email")
+	m.Add(&SQLMigration{v: mustVersion("3.0.0-add-phone"), Path: "3.0.0-add-phone.sql"})
+
+	err := m.Baseline("2.0.0")
+	if err == nil {
+		t.Error("baseline with unknown version should fail")
+	}
+
+	err = m.Baseline("2.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.versions) != 2 || c.versions[0] != "1-create-users" || c.versions[1] != "2.1.0-add-email" {
+		t.Errorf("unexpected versions %v", c.versions)
+	}
+
+	if len(c.history) != 2 || c.history[0].Event != HistoryBaseline {
+		t.Errorf("unexpected history %+v", c.history)
+	}
+
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.sqls) != 1 || c.sqls[0] != "UP SQL 3;" {
+		t.Errorf("only the migration after baseline should be applied, got %q", c.sqls)
+	}
+}
+
+func mustVersion(s string) Version {
+	v, err := VersionFromString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return *v
+}
@@ -0,0 +1,306 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/walkline/migo"
+)
+
+var errNoConnection = errors.New(`command requires database connection, build your own binary:
+
+	app := &cli.App{
+		Path: "migrations",
+		Connect: func() (migo.Connection, error) {
+			return gormconnection.NewConnection(db), nil
+		},
+	}
+	err := app.Run(os.Args[1:])`)
+
+// App is migo command line application. Commands that work with files
+// are available in `migo` binary, commands that work with database
+// need Connect, so they are available when App is embedded into
+// your own binary.
+type App struct {
+	// Path is the directory with migrations, templates and config.
+	Path string
+
+	// Connect opens connection to the database.
+	Connect func() (migo.Connection, error)
+
+	// Scratch opens connection to an empty scratch database,
+	// it is used by squash, diff and `new sql -from-schema` to replay
+	// migrations. Every call must return a different database.
+	Scratch func() (migo.Connection, error)
+
+	// Dump returns schema of the scratch database as sql script, it is
+	// used by squash when scratch connection can't dump schema itself,
+	// e.g. gorm connection to postgres. See migo.SquashOptions.Dump.
+	Dump func(c migo.Connection) (string, error)
+
+	// Loaders returns migration loaders. By default sql migrations
+	// from Path and go migrations of the set are loaded.
+	Loaders func() []migo.MigrationLoader
+
+	// Out is the writer for command output, by default it is stdout.
+	Out io.Writer
+
+	version     string
+	set         string
+	tags        string
+	excludeTags string
+}
+
+type command struct {
+	args string
+	desc string
+	// db is true for commands that need Connect or Scratch.
+	db  bool
+	run func(a *App, args []string) error
+}
+
+var commands = map[string]command{
+	"init": {
+		desc: "creates templates and config",
+		run:  (*App).initCommand,
+	},
+	"new": {
+		args: "<sql|go> [-from-models] [-from-schema <file>] <name>",
+		desc: "creates new migration",
+		run:  (*App).newCommand,
+	},
+	"import": {
+		args: "-from <golang-migrate|goose|flyway> [-table t] [-mapping numeric|timestamp] [-dry-run]",
+		desc: "marks migrations applied by other tool as applied",
+		db:   true,
+		run:  (*App).importCommand,
+	},
+	"baseline": {
+		args: "<version>",
+		desc: "marks migrations up to and including version as applied without running them",
+		db:   true,
+		run:  (*App).baselineCommand,
+	},
+	"check": {
+		desc: "validates names and versions of migrations, fails when problems are found",
+		run:  (*App).checkCommand,
+	},
+	"convert": {
+		args: "-from <golang-migrate|goose|dbmate> -src <dir> [-mapping numeric|timestamp] [-dry-run]",
+		desc: "rewrites migrations of other tool into migo files",
+		run:  (*App).convertCommand,
+	},
+	"squash": {
+		args: "-until <version>",
+		desc: "replaces migrations up to version with a single baseline, needs Scratch (and Dump for databases other than sqlite and mysql)",
+		db:   true,
+		run:  (*App).squashCommand,
+	},
+	"diff": {
+		args: "[-skeleton <name>]",
+		desc: "compares database with schema built by migrations, needs Scratch",
+		db:   true,
+		run:  (*App).diffCommand,
+	},
+	"lint": {
+		args: "[-dialect d]",
+		desc: "checks sql migrations for risky statements",
+		run:  (*App).lintCommand,
+	},
+	"seed": {
+		args: "-env <env>",
+		desc: "runs seeds of the environment that are not applied yet",
+		db:   true,
+		run:  (*App).seedCommand,
+	},
+	"status": {
+		desc: "prints applied, pending and skipped migrations, skipped ones with the reason",
+		db:   true,
+		run:  (*App).statusCommand,
+	},
+	"history": {
+		args: "[-limit n]",
+		desc: "prints history of migrations",
+		db:   true,
+		run:  (*App).historyCommand,
+	},
+}
+
+// Run parses arguments (without program name) and runs the command.
+func (a *App) Run(args []string) error {
+	fs := flag.NewFlagSet("migo", flag.ContinueOnError)
+	fs.SetOutput(a.out())
+	fs.StringVar(&a.version, "version", "-1", "set version manualy")
+	fs.StringVar(&a.set, "set", "", "name of migration set, migration is created in the directory of the set")
+	fs.StringVar(&a.tags, "tags", "", "comma separated tags, tagged migrations run only when they have one of them")
+	fs.StringVar(&a.excludeTags, "exclude-tags", "", "comma separated tags, migrations with one of them are skipped")
+	fs.Usage = func() {
+		a.usage(fs)
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if a.Path == "" {
+		a.Path, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	if fs.NArg() < 1 {
+		a.usage(fs)
+		return errors.New("subcommand is required")
+	}
+
+	cmd, found := commands[fs.Arg(0)]
+	if !found {
+		a.usage(fs)
+		return fmt.Errorf("unknown subcommand '%s'", fs.Arg(0))
+	}
+
+	return cmd.run(a, fs.Args()[1:])
+}
+
+func (a *App) usage(fs *flag.FlagSet) {
+	fmt.Fprintln(a.out(), `Usage: migo [flags] <subcommand> [args]
+
+Usage sample:
+	$ migo new go "[MK-2014] Create users table"
+	$ migo new sql "[MK-2015] Clean users table"
+	$ migo -set billing new sql "[MK-2016] Create invoices table"
+	$ migo -tags dev,test status
+
+Subcommands:`)
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if commands[name].db && a.Connect == nil && a.Scratch == nil {
+			continue
+		}
+		a.printCommand(name)
+	}
+
+	if a.Connect == nil && a.Scratch == nil {
+		fmt.Fprintln(a.out(), "\nSubcommands that need database connection, they are available in your own binary\nthat embeds cli.App with Connect (see README):")
+		for _, name := range names {
+			if commands[name].db {
+				a.printCommand(name)
+			}
+		}
+	}
+
+	fmt.Fprintln(a.out(), "\nFlags:")
+	fs.PrintDefaults()
+}
+
+func (a *App) printCommand(name string) {
+	fmt.Fprintf(a.out(), "\t%s %s\n\t\t%s\n", name, commands[name].args, commands[name].desc)
+}
+
+func (a *App) out() io.Writer {
+	if a.Out == nil {
+		return os.Stdout
+	}
+
+	return a.Out
+}
+
+// dir returns directory of migrations of the current set.
+func (a *App) dir() string {
+	if a.set == "" {
+		return a.Path
+	}
+
+	return filepath.Join(a.Path, a.set)
+}
+
+func (a *App) loaders() ([]migo.MigrationLoader, error) {
+	if a.Loaders != nil {
+		return a.Loaders(), nil
+	}
+
+	config, err := migo.LoadConfig(a.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlLoader := migo.NewSQLMigrationLoader(a.dir())
+	sqlLoader.SetVariables(migo.MergeVariables(config.Variables, migo.VariablesFromEnv()))
+	sqlLoader.SetAllowMissingDown(config.AllowMissingDown)
+
+	return append([]migo.MigrationLoader{sqlLoader}, migo.Set(a.set).Loaders()...), nil
+}
+
+func (a *App) connect() (migo.Connection, error) {
+	if a.Connect == nil {
+		return nil, errNoConnection
+	}
+
+	c, err := a.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return migo.Set(a.set).Connection(c)
+}
+
+// scratch opens scratch database for the current set.
+func (a *App) scratch() (migo.Connection, error) {
+	if a.Scratch == nil {
+		return nil, errNoScratch
+	}
+
+	c, err := a.Scratch()
+	if err != nil {
+		return nil, err
+	}
+
+	return migo.Set(a.set).Connection(c)
+}
+
+func (a *App) migrate() (*migo.Migrate, error) {
+	c, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	loaders, err := a.loaders()
+	if err != nil {
+		return nil, err
+	}
+
+	m := migo.NewMigrate(c, loaders...)
+	m.SetOutput(a.out())
+	m.SetTagFilter(migo.TagFilter{
+		Include: splitList(a.tags),
+		Exclude: splitList(a.excludeTags),
+	})
+
+	return m, nil
+}
+
+// splitList splits comma separated flag value, empty items are dropped.
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
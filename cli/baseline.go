@@ -0,0 +1,16 @@
+package cli
+
+import "errors"
+
+func (a *App) baselineCommand(args []string) error {
+	if len(args) < 1 {
+		return errors.New("version required")
+	}
+
+	m, err := a.migrate()
+	if err != nil {
+		return err
+	}
+
+	return m.Baseline(args[0])
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/walkline/migo"
+)
+
+func (a *App) checkCommand(args []string) error {
+	loaders, err := a.loaders()
+	if err != nil {
+		return err
+	}
+
+	report, err := migo.NewMigrate(nil, loaders...).Validate()
+	if err != nil {
+		return err
+	}
+
+	if report.OK() {
+		fmt.Fprintln(a.out(), "Migrations are valid.")
+		return nil
+	}
+
+	fmt.Fprint(a.out(), report)
+
+	return errors.New("migrations have problems")
+}
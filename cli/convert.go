@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/walkline/migo"
+)
+
+func (a *App) convertCommand(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(a.out())
+	from := fs.String("from", "", "source tool: golang-migrate, goose or dbmate")
+	src := fs.String("src", "", "directory with migrations of the source tool")
+	mapping := fs.String("mapping", "numeric", "version mapping: numeric or timestamp")
+	dryRun := fs.Bool("dry-run", false, "print report without writing files")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *src == "" {
+		return errors.New("-src is required")
+	}
+
+	versionMapping, found := versionMappings[*mapping]
+	if !found {
+		return fmt.Errorf("unknown version mapping '%s'", *mapping)
+	}
+
+	report, err := migo.Convert(migo.ConvertOptions{
+		Source:  migo.ConvertSource(*from),
+		SrcDir:  *src,
+		DstDir:  a.dir(),
+		Mapping: versionMapping,
+		DryRun:  *dryRun,
+	})
+	if report != nil {
+		fmt.Fprint(a.out(), report)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Fprintf(a.out(), "Dry run: %d migration(s) would be converted, %d problem(s).\n", len(report.Converted), len(report.Problems))
+	} else {
+		fmt.Fprintf(a.out(), "Converted %d migration(s), %d problem(s).\n", len(report.Converted), len(report.Problems))
+	}
+
+	return nil
+}
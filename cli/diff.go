@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/walkline/migo"
+)
+
+func (a *App) diffCommand(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(a.out())
+	skeleton := fs.String("skeleton", "", "name of sql migration with corrective statements")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	scratch, err := a.scratch()
+	if err != nil {
+		return err
+	}
+
+	m, err := a.migrate()
+	if err != nil {
+		return err
+	}
+
+	report, err := m.Diff(scratch)
+	if err != nil {
+		return err
+	}
+
+	if !report.HasDrift() {
+		fmt.Fprintln(a.out(), "Database matches migrations.")
+		return nil
+	}
+
+	fmt.Fprint(a.out(), report)
+
+	if *skeleton == "" {
+		return nil
+	}
+
+	tmpl, v, err := a.newMigration(*skeleton)
+	if err != nil {
+		return err
+	}
+
+	up, down := report.Skeleton()
+	err = a.writeSQLMigration(tmpl, v, map[string]interface{}{
+		"set":  a.set,
+		"up":   up,
+		"down": down,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out(), "Migration '%s' is created, review it before applying.\n", v)
+	a.warnUnresolved(report)
+
+	return nil
+}
+
+// warnUnresolved warns about drifts that are left in the skeleton as TODO comments.
+func (a *App) warnUnresolved(report *migo.DriftReport) {
+	if n := len(report.Unresolved()); n > 0 {
+		fmt.Fprintf(a.out(), "Warning: %d change(s) are left as TODO comments, the migration is incomplete until they are resolved.\n", n)
+	}
+}
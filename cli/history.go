@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+func (a *App) historyCommand(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.SetOutput(a.out())
+	limit := fs.Int("limit", 0, "print only the latest n entries")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	m, err := a.migrate()
+	if err != nil {
+		return err
+	}
+
+	entries, err := m.History()
+	if err != nil {
+		return err
+	}
+
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[len(entries)-*limit:]
+	}
+
+	w := tabwriter.NewWriter(a.out(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED AT\tEVENT\tVERSION\tOUTCOME\tDURATION\tUSER\tHOST\tCOMMIT\tERROR")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%v\t%s\t%s\t%s\t%s\n",
+			e.StartedAt.Format(time.RFC3339),
+			e.Event,
+			e.Version,
+			e.Outcome,
+			e.Duration,
+			e.User,
+			e.Hostname,
+			e.GitCommit,
+			e.Error,
+		)
+	}
+
+	return w.Flush()
+}
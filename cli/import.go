@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/walkline/migo"
+)
+
+var versionMappings = map[string]migo.VersionMapping{
+	"numeric":   migo.NumericVersionMapping,
+	"timestamp": migo.TimestampVersionMapping,
+}
+
+func (a *App) importCommand(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(a.out())
+	from := fs.String("from", "", "source tool: golang-migrate, goose or flyway")
+	table := fs.String("table", "", "table of the source tool, default one is used when empty")
+	mapping := fs.String("mapping", "numeric", "version mapping: numeric or timestamp")
+	dryRun := fs.Bool("dry-run", false, "print plan without writing versions")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	versionMapping, found := versionMappings[*mapping]
+	if !found {
+		return fmt.Errorf("unknown version mapping '%s'", *mapping)
+	}
+
+	m, err := a.migrate()
+	if err != nil {
+		return err
+	}
+
+	plan, err := m.Import(migo.ImportOptions{
+		Source:  migo.ImportSource(*from),
+		Table:   *table,
+		Mapping: versionMapping,
+		DryRun:  *dryRun,
+	})
+	if plan != nil {
+		fmt.Fprint(a.out(), plan)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Fprintf(a.out(), "Dry run: %d version(s) would be imported.\n", len(plan.ToApply))
+	} else {
+		fmt.Fprintf(a.out(), "Imported %d version(s).\n", len(plan.ToApply))
+	}
+
+	return nil
+}
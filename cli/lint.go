@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/walkline/migo"
+	"github.com/walkline/migo/sqlscanner"
+)
+
+func (a *App) lintCommand(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(a.out())
+	dialect := fs.String("dialect", "", "dialect of migrations, e.g. postgres")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	config, err := migo.LoadConfig(a.Path)
+	if err != nil {
+		return err
+	}
+
+	loaders, err := a.loaders()
+	if err != nil {
+		return err
+	}
+
+	var c migo.Connection
+	if a.Connect != nil && *dialect == "" {
+		c, err = a.connect()
+		if err != nil {
+			return err
+		}
+	}
+
+	m := migo.NewMigrate(c, loaders...)
+	m.SetOutput(a.out())
+
+	report, err := m.Lint(migo.LintOptions{
+		Rules:   config.Lint,
+		Dialect: sqlscanner.Dialect(*dialect),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(a.out(), report)
+
+	if report.HasErrors() {
+		return errors.New("lint found errors, fix them or allow with '-- migo:allow <rule>'")
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/walkline/migo"
+)
+
+func (a *App) initCommand(args []string) error {
+	t := migo.Templater{}
+	err := t.LoadTemplates(a.Path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(migo.ConfigPath(a.Path)); os.IsNotExist(err) {
+		return (&migo.Config{Variables: map[string]string{}}).Save(a.Path)
+	}
+
+	return nil
+}
+
+func (a *App) newCommand(args []string) error {
+	if len(args) < 1 {
+		return errors.New("type and name required")
+	}
+
+	fs := flag.NewFlagSet("new "+args[0], flag.ContinueOnError)
+	fs.SetOutput(a.out())
+	fromModels := fs.Bool("from-models", false, "go migration creates missing tables, columns and indexes of models of the connection")
+	fromSchema := fs.String("from-schema", "", "sql migration brings schema built by migrations to the schema of the sql file, needs Scratch")
+	err := fs.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return errors.New("type and name required")
+	}
+
+	data := map[string]interface{}{
+		"set": a.set,
+	}
+
+	switch args[0] {
+	case "sql":
+		if *fromModels {
+			return errors.New("-from-models is supported only by go migrations")
+		}
+
+		var report *migo.DriftReport
+		if *fromSchema != "" {
+			report, err = a.schemaFileDiff(*fromSchema)
+			if err != nil {
+				return err
+			}
+
+			if !report.HasDrift() {
+				fmt.Fprintln(a.out(), "Migrations match the schema.")
+				return nil
+			}
+
+			fmt.Fprint(a.out(), report)
+			data["up"], data["down"] = report.Skeleton()
+		}
+
+		tmpl, v, err := a.newMigration(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		err = a.writeSQLMigration(tmpl, v, data)
+		if err != nil {
+			return err
+		}
+
+		if report != nil {
+			a.warnUnresolved(report)
+		}
+
+		return nil
+	case "go":
+		if *fromSchema != "" {
+			return errors.New("-from-schema is supported only by sql migrations")
+		}
+
+		var mm *migo.ModelMigration
+		if *fromModels {
+			mm, err = a.modelMigration()
+			if err != nil {
+				return err
+			}
+
+			if len(mm.Changes) == 0 {
+				fmt.Fprintln(a.out(), "Database matches models.")
+				return nil
+			}
+
+			data["imports"] = mm.Imports
+			data["up"] = mm.Up
+			data["down"] = mm.Down
+		}
+
+		tmpl, v, err := a.newMigration(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		goData, err := tmpl.ContentForTemplateTypeWithData(migo.TemplateTypeGo, v, data)
+		if err != nil {
+			return err
+		}
+
+		if mm != nil && !bytes.Contains(goData, []byte(mm.Up)) {
+			return errors.New("go template doesn't use {{.imports}}, {{.up}} and {{.down}}, see migo/tmpl/go/go.tmpl")
+		}
+
+		err = ioutil.WriteFile(fmt.Sprintf("%s/%s.go", a.dir(), v), goData, 0755)
+		if err != nil {
+			return fmt.Errorf("unable to write file: %w", err)
+		}
+
+		if mm != nil {
+			for _, change := range mm.Changes {
+				fmt.Fprintf(a.out(), "+ %s\n", change)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported migration type '%s'", args[0])
+	}
+
+	return nil
+}
+
+// schemaFileDiff compares schema built by migrations with the schema of sql file,
+// migrations and the file are applied to different scratch databases.
+func (a *App) schemaFileDiff(path string) (*migo.DriftReport, error) {
+	scratch, err := a.scratch()
+	if err != nil {
+		return nil, err
+	}
+
+	desired, err := a.Scratch()
+	if err != nil {
+		return nil, err
+	}
+
+	loaders, err := a.loaders()
+	if err != nil {
+		return nil, err
+	}
+
+	m := migo.NewMigrate(nil, loaders...)
+	m.SetOutput(a.out())
+
+	return m.DiffSchemaFile(path, scratch, desired)
+}
+
+// modelMigration compares models of the connection with the database.
+func (a *App) modelMigration() (*migo.ModelMigration, error) {
+	c, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	differ, ok := c.(migo.ModelDiffer)
+	if !ok {
+		return nil, migo.ErrModelsNotSupported
+	}
+
+	return differ.DiffModels()
+}
+
+// newMigration loads templates and builds version of a new migration
+// in the directory of the current set.
+func (a *App) newMigration(name string) (*migo.Templater, *migo.Version, error) {
+	tmpl := &migo.Templater{}
+	err := tmpl.LoadTemplates(a.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ver := a.version
+	if ver == "-1" {
+		ver, err = tmpl.TampleteWithType(migo.TemplateTypeVersion).BuildVersion()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	v, err := migo.VersionFromString(ver + "-" + strings.Replace(name, " ", "-", -1))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = os.MkdirAll(a.dir(), os.ModePerm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tmpl, v, nil
+}
+
+// writeSQLMigration writes files of sql migration in the format from config,
+// statements can be passed as `up` and `down` data.
+func (a *App) writeSQLMigration(tmpl *migo.Templater, v *migo.Version, data map[string]interface{}) error {
+	config, err := migo.LoadConfig(a.Path)
+	if err != nil {
+		return err
+	}
+	tmpl.SetSQLFormat(config.SQLFormat)
+
+	files, err := tmpl.BuildSQL(v, data)
+	if err != nil {
+		return err
+	}
+
+	for name, content := range files {
+		err = ioutil.WriteFile(fmt.Sprintf("%s/%s", a.dir(), name), content, 0755)
+		if err != nil {
+			return fmt.Errorf("unable to write file: %w", err)
+		}
+	}
+
+	return nil
+}
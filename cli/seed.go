@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+)
+
+func (a *App) seedCommand(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	fs.SetOutput(a.out())
+	env := fs.String("env", "", "environment of seeds, e.g. dev")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *env == "" {
+		return errors.New("-env is required")
+	}
+
+	m, err := a.migrate()
+	if err != nil {
+		return err
+	}
+
+	return m.Seed(*env)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/walkline/migo"
+)
+
+var errNoScratch = errors.New("command requires scratch database, set Scratch of cli.App in your own binary")
+
+func (a *App) squashCommand(args []string) error {
+	fs := flag.NewFlagSet("squash", flag.ContinueOnError)
+	fs.SetOutput(a.out())
+	until := fs.String("until", "", "version of the last squashed migration")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *until == "" {
+		return errors.New("-until is required")
+	}
+
+	scratch, err := a.scratch()
+	if err != nil {
+		return err
+	}
+
+	loaders, err := a.loaders()
+	if err != nil {
+		return err
+	}
+
+	m := migo.NewMigrate(nil, loaders...)
+	m.SetOutput(a.out())
+
+	res, err := m.Squash(migo.SquashOptions{
+		Until:   *until,
+		Scratch: scratch,
+		Dir:     a.dir(),
+		Dump:    a.Dump,
+	})
+	if errors.Is(err, migo.ErrSchemaDumpNotSupported) {
+		return fmt.Errorf("%w, set Dump of cli.App (e.g. running pg_dump)", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out(), "Archived %d file(s).\n", len(res.Archived))
+	for _, v := range res.Remaining {
+		fmt.Fprintf(a.out(), "Go migration '%s' is superseded, remove it from code.\n", v)
+	}
+
+	return nil
+}
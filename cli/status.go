@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+func (a *App) statusCommand(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.SetOutput(a.out())
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	m, err := a.migrate()
+	if err != nil {
+		return err
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tTAGS\tREASON")
+	for _, s := range statuses {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Version, s.State, strings.Join(s.Tags, ","), s.Reason)
+	}
+
+	return w.Flush()
+}
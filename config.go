@@ -0,0 +1,65 @@
+package migo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// Config is migo configuration shared by the command line tool
+// and the application, it is stored in `migo/config.json`.
+type Config struct {
+	// Variables are substituted into sql migrations, see ExpandVariables.
+	Variables map[string]string `json:"variables,omitempty"`
+
+	// SQLFormat is the format of sql migrations created by `migo new sql`.
+	SQLFormat SQLFormat `json:"sql_format,omitempty"`
+
+	// AllowMissingDown allows sql migrations without down part,
+	// such migrations are irreversible.
+	AllowMissingDown bool `json:"allow_missing_down,omitempty"`
+
+	// Lint overrides severities of rules of `migo lint`, see DefaultLintRules.
+	Lint map[LintRule]LintSeverity `json:"lint,omitempty"`
+}
+
+// LoadConfig reads `migo/config.json` from `path`.
+// Returns empty config if file doesn't exist.
+func LoadConfig(path string) (*Config, error) {
+	c := &Config{}
+
+	data, err := ioutil.ReadFile(ConfigPath(path))
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Save writes config to `migo/config.json` inside of `path`.
+func (c *Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path+"/migo", os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(ConfigPath(path), append(data, '\n'), 0644)
+}
+
+// ConfigPath returns path of config file for the project at `path`.
+func ConfigPath(path string) string {
+	return path + "/migo/config.json"
+}
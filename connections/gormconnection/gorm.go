@@ -1,14 +1,20 @@
 package gormconnection
 
 import (
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/walkline/migo"
+	"github.com/walkline/migo/sqlscanner"
 	"gorm.io/gorm"
 )
 
 const (
 	NoVer = "0-null"
+
+	// DefaultVersionTable is the table where versions are stored by default.
+	DefaultVersionTable = "db_versions"
 )
 
 type DBVersion struct {
@@ -18,34 +24,120 @@ type DBVersion struct {
 
 type GormConnection struct {
 	DB *gorm.DB
+
+	table            string
+	historyTableName string
+	seedTableName    string
+	schema           string
+	columns          []Column
+	models           []interface{}
+
+	tableMu      sync.Mutex
+	tableReady   bool
+	historyReady bool
+	seedsReady   bool
 }
 
-func NewConnection(c *gorm.DB) *GormConnection {
-	return &GormConnection{
-		DB: c,
+// NewConnection creates connection that stores versions in `db_versions` table,
+// use options to change the table or to store more information about migrations.
+func NewConnection(c *gorm.DB, opts ...Option) *GormConnection {
+	conn := &GormConnection{
+		DB:               c,
+		table:            DefaultVersionTable,
+		historyTableName: DefaultHistoryTable,
+		seedTableName:    DefaultSeedTable,
 	}
+
+	for _, opt := range opts {
+		opt(conn)
+	}
+
+	return conn
 }
 
 func (c *GormConnection) Exec(sql string, values ...interface{}) error {
 	return c.DB.Exec(sql, values...).Error
 }
 
-func (c *GormConnection) LoadVersions() ([]string, error) {
-	var v []DBVersion
-	if err := c.DB.Find(&v).Error; err != nil {
-		// maybe we need to create table...
-		if err = c.DB.AutoMigrate(&DBVersion{}); err != nil {
+// Query returns rows of the query, values are keyed by column names.
+// Byte slices are converted to strings.
+func (c *GormConnection) Query(sql string, values ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := c.DB.Raw(sql, values...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		vals := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+
+		err := rows.Scan(ptrs...)
+		if err != nil {
 			return nil, err
 		}
 
-		if err = c.DB.Find(&v).Error; err != nil {
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			if b, ok := vals[i].([]byte); ok {
+				vals[i] = string(b)
+			}
+			row[strings.ToLower(col)] = vals[i]
+		}
+
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// ForSet returns connection that stores versions of migration set
+// in its own table, e.g. `db_versions_billing`.
+func (c *GormConnection) ForSet(name string) migo.Connection {
+	return &GormConnection{
+		DB:               c.DB,
+		table:            c.table + "_" + name,
+		historyTableName: c.historyTableName + "_" + name,
+		seedTableName:    c.seedTableName + "_" + name,
+		schema:           c.schema,
+		columns:          c.columns,
+		models:           c.models,
+	}
+}
+
+func (c *GormConnection) LoadVersions() ([]string, error) {
+	err := c.ensureVersionTable()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.DB.Raw("SELECT " + c.quote("version") + " FROM " + c.versionTable()).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vers := []string{}
+	for rows.Next() {
+		var ver string
+		if err := rows.Scan(&ver); err != nil {
 			return nil, err
 		}
+
+		vers = append(vers, ver)
 	}
 
-	vers := make([]string, len(v))
-	for i, ver := range v {
-		vers[i] = ver.Version
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	if len(vers) == 0 {
@@ -56,10 +148,47 @@ func (c *GormConnection) LoadVersions() ([]string, error) {
 }
 
 func (c *GormConnection) SetVersion(ver string) error {
-	return c.DB.Save(&DBVersion{
-		Date:    time.Now(),
+	return c.RecordVersion(migo.VersionRecord{
 		Version: ver,
-	}).Error
+	})
+}
+
+// RemoveVersion removes version from the version table.
+func (c *GormConnection) RemoveVersion(ver string) error {
+	err := c.ensureVersionTable()
+	if err != nil {
+		return err
+	}
+
+	return c.DB.Exec("DELETE FROM "+c.versionTable()+" WHERE "+c.quote("version")+" = ?", ver).Error
+}
+
+// RecordVersion stores applied version with values of the extra columns.
+func (c *GormConnection) RecordVersion(r migo.VersionRecord) error {
+	err := c.ensureVersionTable()
+	if err != nil {
+		return err
+	}
+
+	names := []string{c.quote("date"), c.quote("version")}
+	values := []interface{}{time.Now(), r.Version}
+	for _, col := range c.columns {
+		names = append(names, c.quote(string(col)))
+		values = append(values, col.value(r))
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+
+	return c.DB.Exec(
+		"INSERT INTO "+c.versionTable()+" ("+strings.Join(names, ", ")+") VALUES ("+placeholders+")",
+		values...,
+	).Error
+}
+
+// Dialect returns name of gorm dialector, it is used
+// to split sql migrations into statements.
+func (c *GormConnection) Dialect() sqlscanner.Dialect {
+	return sqlscanner.Dialect(c.DB.Dialector.Name())
 }
 
 func (c *GormConnection) Tx() (migo.Transaction, error) {
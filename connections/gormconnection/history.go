@@ -0,0 +1,164 @@
+package gormconnection
+
+import (
+	"strings"
+	"time"
+
+	"github.com/walkline/migo"
+)
+
+// DefaultHistoryTable is the table where history is stored by default.
+const DefaultHistoryTable = "migo_history"
+
+var historyColumns = []string{
+	"version",
+	"event",
+	"started_at",
+	"duration_ms",
+	"os_user",
+	"hostname",
+	"git_commit",
+	"outcome",
+	"error_message",
+}
+
+// AppendHistory appends entry to the history table.
+func (c *GormConnection) AppendHistory(e migo.HistoryEntry) error {
+	err := c.ensureHistoryTable()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(historyColumns))
+	for i, col := range historyColumns {
+		names[i] = c.quote(col)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+
+	return c.DB.Exec(
+		"INSERT INTO "+c.historyTable()+" ("+strings.Join(names, ", ")+") VALUES ("+placeholders+")",
+		e.Version,
+		string(e.Event),
+		e.StartedAt,
+		e.Duration.Milliseconds(),
+		e.User,
+		e.Hostname,
+		e.GitCommit,
+		string(e.Outcome),
+		e.Error,
+	).Error
+}
+
+// LoadHistory returns all entries of the history table in the order they were appended.
+func (c *GormConnection) LoadHistory() ([]migo.HistoryEntry, error) {
+	err := c.ensureHistoryTable()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(historyColumns))
+	for i, col := range historyColumns {
+		names[i] = c.quote(col)
+	}
+
+	rows, err := c.DB.Raw(
+		"SELECT " + strings.Join(names, ", ") + " FROM " + c.historyTable() + " ORDER BY " + c.quote("id"),
+	).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []migo.HistoryEntry{}
+	for rows.Next() {
+		var (
+			e               migo.HistoryEntry
+			event, outcome  string
+			durationMs      int64
+			commit, errText *string
+		)
+
+		err := rows.Scan(&e.Version, &event, &e.StartedAt, &durationMs, &e.User, &e.Hostname, &commit, &outcome, &errText)
+		if err != nil {
+			return nil, err
+		}
+
+		e.Event = migo.HistoryEvent(event)
+		e.Outcome = migo.HistoryOutcome(outcome)
+		e.Duration = time.Duration(durationMs) * time.Millisecond
+		if commit != nil {
+			e.GitCommit = *commit
+		}
+		if errText != nil {
+			e.Error = *errText
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// historyTable returns quoted name of the history table.
+func (c *GormConnection) historyTable() string {
+	if c.schema == "" {
+		return c.quote(c.historyTableName)
+	}
+
+	return c.quote(c.schema) + "." + c.quote(c.historyTableName)
+}
+
+// ensureHistoryTable creates history table if it doesn't exist.
+func (c *GormConnection) ensureHistoryTable() error {
+	c.tableMu.Lock()
+	defer c.tableMu.Unlock()
+
+	if c.historyReady {
+		return nil
+	}
+
+	if _, found := c.tableColumns(c.historyTable()); !found {
+		err := c.DB.Exec(c.createHistoryTableSQL()).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	c.historyReady = true
+
+	return nil
+}
+
+func (c *GormConnection) createHistoryTableSQL() string {
+	dialect := c.DB.Dialector.Name()
+
+	idType := "BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY"
+	textType := "TEXT"
+	switch dialect {
+	case "postgres":
+		idType = "BIGSERIAL PRIMARY KEY"
+	case "mysql":
+		idType = "BIGINT AUTO_INCREMENT PRIMARY KEY"
+	case "sqlite":
+		idType = "INTEGER PRIMARY KEY AUTOINCREMENT"
+	case "sqlserver":
+		idType = "BIGINT IDENTITY(1,1) PRIMARY KEY"
+		textType = "NVARCHAR(MAX)"
+	}
+
+	columns := []string{
+		c.quote("id") + " " + idType,
+		c.quote("version") + " " + varcharType(dialect),
+		c.quote("event") + " " + varcharType(dialect),
+		c.quote("started_at") + " " + dateType(dialect),
+		c.quote("duration_ms") + " BIGINT",
+		c.quote("os_user") + " " + varcharType(dialect),
+		c.quote("hostname") + " " + varcharType(dialect),
+		c.quote("git_commit") + " " + varcharType(dialect),
+		c.quote("outcome") + " " + varcharType(dialect),
+		c.quote("error_message") + " " + textType,
+	}
+
+	return "CREATE TABLE " + c.historyTable() + " (" + strings.Join(columns, ", ") + ")"
+}
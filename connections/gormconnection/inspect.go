@@ -0,0 +1,348 @@
+package gormconnection
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/walkline/migo"
+)
+
+// InspectSchema describes tables, columns, indexes and constraints of the database,
+// version and history tables are skipped. Supports sqlite, postgres and mysql.
+func (c *GormConnection) InspectSchema() (*migo.Schema, error) {
+	var (
+		s   *migo.Schema
+		err error
+	)
+
+	switch c.DB.Dialector.Name() {
+	case "sqlite":
+		s, err = c.inspectSQLite()
+	case "postgres":
+		s, err = c.inspectPostgres()
+	case "mysql":
+		s, err = c.inspectMySQL()
+	default:
+		return nil, fmt.Errorf("schema inspection is not supported for %s", c.DB.Dialector.Name())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.Sort()
+
+	return s, nil
+}
+
+func (c *GormConnection) inspectSQLite() (*migo.Schema, error) {
+	tables, err := c.Query(
+		"SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT IN (?, ?, ?)",
+		c.table, c.historyTableName, c.seedTableName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &migo.Schema{}
+	for _, row := range tables {
+		t := migo.Table{Name: asString(row["name"])}
+
+		columns, err := c.Query("PRAGMA table_info(" + c.quote(t.Name) + ")")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, col := range columns {
+			t.Columns = append(t.Columns, migo.Column{
+				Name:     asString(col["name"]),
+				Type:     strings.ToUpper(asString(col["type"])),
+				Nullable: asString(col["notnull"]) == "0" && asString(col["pk"]) == "0",
+				Default:  asString(col["dflt_value"]),
+			})
+		}
+
+		indexes, err := c.Query("PRAGMA index_list(" + c.quote(t.Name) + ")")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, idx := range indexes {
+			i := migo.Index{
+				Name:   asString(idx["name"]),
+				Unique: asString(idx["unique"]) == "1",
+			}
+
+			indexColumns, err := c.Query("PRAGMA index_info(" + c.quote(i.Name) + ")")
+			if err != nil {
+				return nil, err
+			}
+
+			for _, col := range indexColumns {
+				i.Columns = append(i.Columns, asString(col["name"]))
+			}
+
+			t.Indexes = append(t.Indexes, i)
+		}
+
+		t.Constraints, err = c.sqliteConstraints(t.Name, columns)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Tables = append(s.Tables, t)
+	}
+
+	return s, nil
+}
+
+// sqliteConstraints returns primary and foreign keys of the table,
+// unique constraints are described by sqlite as indexes.
+func (c *GormConnection) sqliteConstraints(table string, columns []map[string]interface{}) ([]migo.Constraint, error) {
+	constraints := []migo.Constraint{}
+
+	pk := migo.Constraint{Type: "PRIMARY KEY"}
+	for n := 1; n <= len(columns); n++ {
+		for _, col := range columns {
+			if asString(col["pk"]) == fmt.Sprint(n) {
+				pk.Columns = append(pk.Columns, asString(col["name"]))
+			}
+		}
+	}
+	if len(pk.Columns) > 0 {
+		constraints = append(constraints, pk)
+	}
+
+	keys, err := c.Query("PRAGMA foreign_key_list(" + c.quote(table) + ")")
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]*migo.Constraint{}
+	refColumns := map[string][]string{}
+	ids := []string{}
+	for _, key := range keys {
+		id := asString(key["id"])
+		fk, found := byID[id]
+		if !found {
+			fk = &migo.Constraint{Type: "FOREIGN KEY", References: asString(key["table"])}
+			byID[id] = fk
+			ids = append(ids, id)
+		}
+
+		fk.Columns = append(fk.Columns, asString(key["from"]))
+		refColumns[id] = append(refColumns[id], asString(key["to"]))
+	}
+
+	for _, id := range ids {
+		fk := byID[id]
+		fk.References += "(" + strings.Join(refColumns[id], ", ") + ")"
+		constraints = append(constraints, *fk)
+	}
+
+	return constraints, nil
+}
+
+func (c *GormConnection) inspectPostgres() (*migo.Schema, error) {
+	schema := "current_schema()"
+	args := []interface{}{c.table, c.historyTableName, c.seedTableName}
+	if c.schema != "" {
+		schema = "?"
+		args = append([]interface{}{c.schema}, args...)
+	}
+
+	columns, err := c.Query(
+		"SELECT c.table_name, c.column_name, c.data_type, c.is_nullable, c.column_default "+
+			"FROM information_schema.columns c JOIN information_schema.tables t "+
+			"ON t.table_schema = c.table_schema AND t.table_name = c.table_name "+
+			"WHERE t.table_type = 'BASE TABLE' AND c.table_schema = "+schema+" AND c.table_name NOT IN (?, ?, ?) "+
+			"ORDER BY c.table_name, c.ordinal_position",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &migo.Schema{}
+	for _, col := range columns {
+		t := tableOf(s, asString(col["table_name"]))
+		t.Columns = append(t.Columns, migo.Column{
+			Name:     asString(col["column_name"]),
+			Type:     strings.ToUpper(asString(col["data_type"])),
+			Nullable: asString(col["is_nullable"]) == "YES",
+			Default:  asString(col["column_default"]),
+		})
+	}
+
+	indexes, err := c.Query(
+		"SELECT tablename, indexname, indexdef FROM pg_indexes WHERE schemaname = "+schema+" AND tablename NOT IN (?, ?, ?)",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idx := range indexes {
+		def := asString(idx["indexdef"])
+		i := migo.Index{
+			Name:   asString(idx["indexname"]),
+			Unique: strings.HasPrefix(strings.ToUpper(def), "CREATE UNIQUE"),
+		}
+
+		if start, end := strings.Index(def, "("), strings.LastIndex(def, ")"); start >= 0 && end > start {
+			for _, col := range strings.Split(def[start+1:end], ",") {
+				i.Columns = append(i.Columns, strings.Trim(strings.TrimSpace(col), `"`))
+			}
+		}
+
+		t := tableOf(s, asString(idx["tablename"]))
+		t.Indexes = append(t.Indexes, i)
+	}
+
+	constraints, err := c.Query(
+		"SELECT tc.table_name, tc.constraint_name, tc.constraint_type, kcu.column_name, "+
+			"ccu.table_name AS ref_table, ccu.column_name AS ref_column "+
+			"FROM information_schema.table_constraints tc "+
+			"JOIN information_schema.key_column_usage kcu "+
+			"ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name "+
+			"LEFT JOIN information_schema.constraint_column_usage ccu "+
+			"ON tc.constraint_type = 'FOREIGN KEY' AND ccu.constraint_schema = tc.constraint_schema AND ccu.constraint_name = tc.constraint_name "+
+			"WHERE tc.table_schema = "+schema+" AND tc.table_name NOT IN (?, ?, ?) "+
+			"AND tc.constraint_type IN ('PRIMARY KEY', 'FOREIGN KEY', 'UNIQUE') "+
+			"ORDER BY tc.table_name, tc.constraint_name, kcu.ordinal_position",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	addConstraints(s, constraints)
+
+	return s, nil
+}
+
+func (c *GormConnection) inspectMySQL() (*migo.Schema, error) {
+	columns, err := c.Query(
+		"SELECT c.table_name AS table_name, c.column_name AS column_name, c.column_type AS column_type, "+
+			"c.is_nullable AS is_nullable, c.column_default AS column_default "+
+			"FROM information_schema.columns c JOIN information_schema.tables t "+
+			"ON t.table_schema = c.table_schema AND t.table_name = c.table_name "+
+			"WHERE t.table_type = 'BASE TABLE' AND c.table_schema = DATABASE() AND c.table_name NOT IN (?, ?, ?) "+
+			"ORDER BY c.table_name, c.ordinal_position",
+		c.table, c.historyTableName, c.seedTableName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &migo.Schema{}
+	for _, col := range columns {
+		t := tableOf(s, asString(col["table_name"]))
+		t.Columns = append(t.Columns, migo.Column{
+			Name:     asString(col["column_name"]),
+			Type:     strings.ToUpper(asString(col["column_type"])),
+			Nullable: asString(col["is_nullable"]) == "YES",
+			Default:  asString(col["column_default"]),
+		})
+	}
+
+	indexes, err := c.Query(
+		"SELECT table_name AS table_name, index_name AS index_name, non_unique AS non_unique, column_name AS column_name "+
+			"FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name NOT IN (?, ?, ?) "+
+			"ORDER BY table_name, index_name, seq_in_index",
+		c.table, c.historyTableName, c.seedTableName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idx := range indexes {
+		t := tableOf(s, asString(idx["table_name"]))
+		name := asString(idx["index_name"])
+
+		if len(t.Indexes) == 0 || t.Indexes[len(t.Indexes)-1].Name != name {
+			t.Indexes = append(t.Indexes, migo.Index{
+				Name:   name,
+				Unique: asString(idx["non_unique"]) == "0",
+			})
+		}
+
+		i := &t.Indexes[len(t.Indexes)-1]
+		i.Columns = append(i.Columns, asString(idx["column_name"]))
+	}
+
+	constraints, err := c.Query(
+		"SELECT tc.table_name AS table_name, tc.constraint_name AS constraint_name, tc.constraint_type AS constraint_type, "+
+			"kcu.column_name AS column_name, kcu.referenced_table_name AS ref_table, kcu.referenced_column_name AS ref_column "+
+			"FROM information_schema.table_constraints tc "+
+			"JOIN information_schema.key_column_usage kcu "+
+			"ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name AND kcu.table_name = tc.table_name "+
+			"WHERE tc.table_schema = DATABASE() AND tc.table_name NOT IN (?, ?, ?) "+
+			"AND tc.constraint_type IN ('PRIMARY KEY', 'FOREIGN KEY', 'UNIQUE') "+
+			"ORDER BY tc.table_name, tc.constraint_name, kcu.ordinal_position",
+		c.table, c.historyTableName, c.seedTableName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	addConstraints(s, constraints)
+
+	return s, nil
+}
+
+// addConstraints adds constraints from rows of information_schema,
+// a row per column of constraint.
+func addConstraints(s *migo.Schema, rows []map[string]interface{}) {
+	refColumns := map[string][]string{}
+	for _, row := range rows {
+		t := tableOf(s, asString(row["table_name"]))
+		name := asString(row["constraint_name"])
+
+		if len(t.Constraints) == 0 || t.Constraints[len(t.Constraints)-1].Name != name {
+			t.Constraints = append(t.Constraints, migo.Constraint{
+				Name: name,
+				Type: asString(row["constraint_type"]),
+			})
+		}
+
+		con := &t.Constraints[len(t.Constraints)-1]
+		key := t.Name + "." + name
+		if column := asString(row["column_name"]); !contains(con.Columns, column) {
+			con.Columns = append(con.Columns, column)
+		}
+
+		if ref := asString(row["ref_table"]); ref != "" {
+			if column := asString(row["ref_column"]); !contains(refColumns[key], column) {
+				refColumns[key] = append(refColumns[key], column)
+			}
+			con.References = ref + "(" + strings.Join(refColumns[key], ", ") + ")"
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tableOf returns table of the schema, table is added when it is missing.
+func tableOf(s *migo.Schema, name string) *migo.Table {
+	if t := s.Table(name); t != nil {
+		return t
+	}
+
+	s.Tables = append(s.Tables, migo.Table{Name: name})
+
+	return &s.Tables[len(s.Tables)-1]
+}
+
+func asString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+
+	return fmt.Sprint(v)
+}
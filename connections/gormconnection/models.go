@@ -0,0 +1,154 @@
+package gormconnection
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/walkline/migo"
+	"gorm.io/gorm"
+)
+
+// WithModels registers models of the application, `migo new go --from-models`
+// generates migration that creates their missing tables, columns and indexes.
+// Tables are created in the order of models.
+func WithModels(models ...interface{}) Option {
+	return func(c *GormConnection) {
+		c.models = append(c.models, models...)
+	}
+}
+
+type modelStatement struct {
+	up     string
+	down   string
+	change string
+}
+
+// DiffModels compares registered models with the database using gorm Migrator.
+// Up of returned migration creates missing tables, columns and indexes,
+// Down drops them in the reverse order.
+func (c *GormConnection) DiffModels() (*migo.ModelMigration, error) {
+	if len(c.models) == 0 {
+		return nil, migo.ErrModelsNotSupported
+	}
+
+	migrator := c.DB.Migrator()
+	imports := map[string]bool{}
+	statements := []modelStatement{}
+	for _, model := range c.models {
+		stmt := &gorm.Statement{DB: c.DB}
+		err := stmt.Parse(model)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse model %T: %w", model, err)
+		}
+
+		t := reflect.TypeOf(model)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		spec, err := importSpec(t.PkgPath(), strings.SplitN(t.String(), ".", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("can't import model %T: %w", model, err)
+		}
+		imports[spec] = true
+		value := "&" + t.String() + "{}"
+
+		if !migrator.HasTable(model) {
+			statements = append(statements, modelStatement{
+				up:     fmt.Sprintf("CreateTable(%s)", value),
+				down:   fmt.Sprintf("DropTable(%s)", value),
+				change: "table " + stmt.Schema.Table,
+			})
+			continue
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || migrator.HasColumn(model, field.DBName) {
+				continue
+			}
+
+			statements = append(statements, modelStatement{
+				up:     fmt.Sprintf("AddColumn(%s, %q)", value, field.Name),
+				down:   fmt.Sprintf("DropColumn(%s, %q)", value, field.Name),
+				change: "column " + stmt.Schema.Table + "." + field.DBName,
+			})
+		}
+
+		indexes := stmt.Schema.ParseIndexes()
+		names := make([]string, 0, len(indexes))
+		for name := range indexes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if migrator.HasIndex(model, name) {
+				continue
+			}
+
+			statements = append(statements, modelStatement{
+				up:     fmt.Sprintf("CreateIndex(%s, %q)", value, name),
+				down:   fmt.Sprintf("DropIndex(%s, %q)", value, name),
+				change: "index " + stmt.Schema.Table + "." + name,
+			})
+		}
+	}
+
+	mm := &migo.ModelMigration{}
+	if len(statements) == 0 {
+		return mm, nil
+	}
+
+	for spec := range imports {
+		mm.Imports = append(mm.Imports, spec)
+	}
+	sort.Strings(mm.Imports)
+
+	ups := make([]string, len(statements))
+	downs := make([]string, len(statements))
+	for i, s := range statements {
+		ups[i] = s.up
+		downs[len(statements)-1-i] = s.down
+		mm.Changes = append(mm.Changes, s.change)
+	}
+	mm.Up = migratorCalls(ups)
+	mm.Down = migratorCalls(downs)
+
+	return mm, nil
+}
+
+// importSpec returns import of the package with the path and the name,
+// the name is added when it differs from the last element of the path.
+// Models of `main` package can't be imported by migrations.
+func importSpec(pkgPath, name string) (string, error) {
+	if name == "main" || pkgPath == "main" {
+		return "", errors.New("models of main package can't be imported, move them to another package")
+	}
+
+	if path.Base(pkgPath) == name {
+		return fmt.Sprintf("%q", pkgPath), nil
+	}
+
+	return fmt.Sprintf("%s %q", name, pkgPath), nil
+}
+
+// migratorCalls returns body of go migration method that makes calls
+// of gorm Migrator one by one.
+func migratorCalls(calls []string) string {
+	b := &strings.Builder{}
+	b.WriteString("\tmigrator := m.DB.Migrator()\n")
+	for i, call := range calls {
+		assign := "="
+		if i == 0 {
+			assign = ":="
+		}
+
+		fmt.Fprintf(b, "\n\terr %s migrator.%s\n\tif err != nil {\n\t\treturn err\n\t}\n", assign, call)
+	}
+	b.WriteString("\n\treturn nil\n")
+
+	return b.String()
+}
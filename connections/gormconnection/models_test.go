@@ -0,0 +1,90 @@
+package gormconnection
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/walkline/migo"
+)
+
+func TestImportSpec(t *testing.T) {
+	spec, err := importSpec("example.com/app/models", "models")
+	if err != nil || spec != `"example.com/app/models"` {
+		t.Errorf("unexpected import %s %v", spec, err)
+	}
+
+	spec, err = importSpec("gopkg.in/app.v2", "app")
+	if err != nil || spec != `app "gopkg.in/app.v2"` {
+		t.Errorf("unexpected import %s %v", spec, err)
+	}
+
+	_, err = importSpec("main", "main")
+	if err == nil {
+		t.Error("models of main package should fail")
+	}
+}
+
+func TestMigratorCalls(t *testing.T) {
+	body := migratorCalls([]string{"CreateTable(&models.User{})", `AddColumn(&models.Post{}, "Title")`})
+
+	expected := "\tmigrator := m.DB.Migrator()\n" +
+		"\n\terr := migrator.CreateTable(&models.User{})\n\tif err != nil {\n\t\treturn err\n\t}\n" +
+		"\n\terr = migrator.AddColumn(&models.Post{}, \"Title\")\n\tif err != nil {\n\t\treturn err\n\t}\n" +
+		"\n\treturn nil\n"
+	if body != expected {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+}
+
+func TestModelMigrationTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpl := &migo.Templater{}
+	err = tmpl.LoadTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	models, _ := importSpec("example.com/app/models", "models")
+	app, _ := importSpec("gopkg.in/app.v2", "app")
+	mm := &migo.ModelMigration{
+		Imports: []string{models, app},
+		Up:      migratorCalls([]string{"CreateTable(&models.User{})", `CreateIndex(&app.Post{}, "idx_title")`}),
+		Down:    migratorCalls([]string{`DropIndex(&app.Post{}, "idx_title")`, "DropTable(&models.User{})"}),
+	}
+
+	v, _ := migo.VersionFromString("1-create-users")
+	content, err := tmpl.ContentForTemplateTypeWithData(migo.TemplateTypeGo, v, map[string]interface{}{
+		"imports": mm.Imports,
+		"up":      mm.Up,
+		"down":    mm.Down,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "1-create-users.go", content, 0)
+	if err != nil {
+		t.Fatalf("generated migration doesn't parse: %v\n%s", err, content)
+	}
+
+	imports := []string{}
+	for _, spec := range f.Imports {
+		imports = append(imports, spec.Path.Value)
+	}
+	if !strings.Contains(strings.Join(imports, " "), `"example.com/app/models" "gopkg.in/app.v2"`) {
+		t.Errorf("unexpected imports %v", imports)
+	}
+
+	if !strings.Contains(string(content), mm.Up) || !strings.Contains(string(content), mm.Down) {
+		t.Errorf("migration doesn't contain up and down:\n%s", content)
+	}
+}
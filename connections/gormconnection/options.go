@@ -0,0 +1,100 @@
+package gormconnection
+
+import (
+	"os"
+
+	"github.com/walkline/migo"
+	"github.com/walkline/migo/internal/osuser"
+)
+
+// Option configures GormConnection.
+type Option func(c *GormConnection)
+
+// WithTableName sets name of the table where versions are stored.
+func WithTableName(name string) Option {
+	return func(c *GormConnection) {
+		c.table = name
+	}
+}
+
+// WithHistoryTable sets name of the table where history of migrations is stored.
+func WithHistoryTable(name string) Option {
+	return func(c *GormConnection) {
+		c.historyTableName = name
+	}
+}
+
+// WithSchema sets schema of the version and history tables,
+// by default the current schema is used.
+func WithSchema(schema string) Option {
+	return func(c *GormConnection) {
+		c.schema = schema
+	}
+}
+
+// WithColumns adds extra columns to the version table.
+// Columns that are missing in the existing table are added to it.
+func WithColumns(columns ...Column) Option {
+	return func(c *GormConnection) {
+		c.columns = append(c.columns, columns...)
+	}
+}
+
+// Column is an extra column of the version table.
+type Column string
+
+const (
+	// ColumnAppliedBy stores name of OS user that applied migration.
+	ColumnAppliedBy Column = "applied_by"
+	// ColumnHostname stores name of the host where migration was applied.
+	ColumnHostname Column = "hostname"
+	// ColumnDurationMs stores duration of migration in milliseconds.
+	ColumnDurationMs Column = "duration_ms"
+	// ColumnChecksum stores checksum of migration, see migo.Checksummer.
+	ColumnChecksum Column = "checksum"
+	// ColumnMigoVersion stores version of migo that applied migration.
+	ColumnMigoVersion Column = "migo_version"
+)
+
+// AllColumns is the list of all supported extra columns.
+var AllColumns = []Column{
+	ColumnAppliedBy,
+	ColumnHostname,
+	ColumnDurationMs,
+	ColumnChecksum,
+	ColumnMigoVersion,
+}
+
+func (col Column) value(r migo.VersionRecord) interface{} {
+	switch col {
+	case ColumnAppliedBy:
+		return osuser.Name()
+	case ColumnHostname:
+		hostname, _ := os.Hostname()
+		return hostname
+	case ColumnDurationMs:
+		return r.Duration.Milliseconds()
+	case ColumnChecksum:
+		return r.Checksum
+	case ColumnMigoVersion:
+		return migo.Release
+	}
+
+	return nil
+}
+
+func (col Column) sqlType(dialect string) string {
+	if col == ColumnDurationMs {
+		return "BIGINT"
+	}
+
+	return varcharType(dialect)
+}
+
+func varcharType(dialect string) string {
+	if dialect == "sqlserver" {
+		return "NVARCHAR(255)"
+	}
+
+	return "VARCHAR(255)"
+}
@@ -0,0 +1,72 @@
+package gormconnection
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/walkline/migo"
+)
+
+// DumpSchema returns sql script that creates tables, views, indexes
+// and triggers of the database, version and history tables are skipped.
+// Only sqlite and mysql are supported, for other databases
+// use migo.SquashOptions.Dump (or Dump of cli.App) with a tool like pg_dump.
+func (c *GormConnection) DumpSchema() (string, error) {
+	switch c.DB.Dialector.Name() {
+	case "sqlite":
+		return c.dumpSQLiteSchema()
+	case "mysql":
+		return c.dumpMySQLSchema()
+	default:
+		return "", migo.ErrSchemaDumpNotSupported
+	}
+}
+
+func (c *GormConnection) dumpSQLiteSchema() (string, error) {
+	rows, err := c.Query(
+		"SELECT sql FROM sqlite_master WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' AND tbl_name NOT IN (?, ?, ?) "+
+			"ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'view' THEN 1 ELSE 2 END, rowid",
+		c.table, c.historyTableName, c.seedTableName,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	statements := make([]string, 0, len(rows))
+	for _, row := range rows {
+		statements = append(statements, fmt.Sprint(row["sql"])+";")
+	}
+
+	return strings.Join(statements, "\n\n"), nil
+}
+
+func (c *GormConnection) dumpMySQLSchema() (string, error) {
+	tables, err := c.Query(
+		"SELECT table_name AS name, table_type AS type FROM information_schema.tables "+
+			"WHERE table_schema = DATABASE() AND table_name NOT IN (?, ?, ?) ORDER BY table_type, table_name",
+		c.table, c.historyTableName, c.seedTableName,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	statements := []string{"SET FOREIGN_KEY_CHECKS = 0;"}
+	for _, table := range tables {
+		rows, err := c.Query("SHOW CREATE TABLE " + c.quote(fmt.Sprint(table["name"])))
+		if err != nil {
+			return "", err
+		}
+
+		for _, row := range rows {
+			create, found := row["create table"]
+			if !found {
+				create = row["create view"]
+			}
+
+			statements = append(statements, fmt.Sprint(create)+";")
+		}
+	}
+	statements = append(statements, "SET FOREIGN_KEY_CHECKS = 1;")
+
+	return strings.Join(statements, "\n\n"), nil
+}
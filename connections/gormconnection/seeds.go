@@ -0,0 +1,91 @@
+package gormconnection
+
+import (
+	"time"
+)
+
+// DefaultSeedTable is the table where applied seeds are stored by default.
+const DefaultSeedTable = "migo_seeds"
+
+// WithSeedTable sets name of the table where applied seeds are stored.
+func WithSeedTable(name string) Option {
+	return func(c *GormConnection) {
+		c.seedTableName = name
+	}
+}
+
+// AppliedSeeds returns names of seeds applied in the environment.
+func (c *GormConnection) AppliedSeeds(env string) ([]string, error) {
+	err := c.ensureSeedTable()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.DB.Raw("SELECT "+c.quote("name")+" FROM "+c.seedTable()+" WHERE "+c.quote("env")+" = ?", env).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// RecordSeed marks seed as applied in the environment.
+func (c *GormConnection) RecordSeed(env, name string, appliedAt time.Time) error {
+	err := c.ensureSeedTable()
+	if err != nil {
+		return err
+	}
+
+	return c.DB.Exec(
+		"INSERT INTO "+c.seedTable()+" ("+c.quote("env")+", "+c.quote("name")+", "+c.quote("applied_at")+") VALUES (?, ?, ?)",
+		env, name, appliedAt,
+	).Error
+}
+
+// seedTable returns quoted name of the seed table.
+func (c *GormConnection) seedTable() string {
+	if c.schema == "" {
+		return c.quote(c.seedTableName)
+	}
+
+	return c.quote(c.schema) + "." + c.quote(c.seedTableName)
+}
+
+// ensureSeedTable creates seed table if it doesn't exist.
+func (c *GormConnection) ensureSeedTable() error {
+	c.tableMu.Lock()
+	defer c.tableMu.Unlock()
+
+	if c.seedsReady {
+		return nil
+	}
+
+	if _, found := c.tableColumns(c.seedTable()); !found {
+		dialect := c.DB.Dialector.Name()
+		err := c.DB.Exec(
+			"CREATE TABLE " + c.seedTable() + " (" +
+				c.quote("env") + " " + varcharType(dialect) + ", " +
+				c.quote("name") + " " + varcharType(dialect) + ", " +
+				c.quote("applied_at") + " " + dateType(dialect) + ")",
+		).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	c.seedsReady = true
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package gormconnection
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// versionTable returns quoted name of the version table.
+func (c *GormConnection) versionTable() string {
+	if c.schema == "" {
+		return c.quote(c.table)
+	}
+
+	return c.quote(c.schema) + "." + c.quote(c.table)
+}
+
+func (c *GormConnection) quote(name string) string {
+	b := &strings.Builder{}
+	c.DB.Dialector.QuoteTo(b, name)
+
+	return b.String()
+}
+
+// ensureVersionTable creates version table if it doesn't exist
+// and adds extra columns that are missing in it.
+func (c *GormConnection) ensureVersionTable() error {
+	c.tableMu.Lock()
+	defer c.tableMu.Unlock()
+
+	if c.tableReady {
+		return nil
+	}
+
+	existing, found := c.tableColumns(c.versionTable())
+	if !found {
+		err := c.DB.Exec(c.createVersionTableSQL()).Error
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, col := range c.columns {
+			if existing[string(col)] {
+				continue
+			}
+
+			err := c.DB.Exec("ALTER TABLE " + c.versionTable() + " ADD " + c.quote(string(col)) + " " + col.sqlType(c.DB.Dialector.Name())).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	c.tableReady = true
+
+	return nil
+}
+
+// tableColumns returns lower cased names of the table columns,
+// `found` is false when table doesn't exist.
+func (c *GormConnection) tableColumns(table string) (columns map[string]bool, found bool) {
+	// missing table is expected here, so error is not logged
+	db := c.DB.Session(&gorm.Session{Logger: c.DB.Logger.LogMode(logger.Silent)})
+
+	rows, err := db.Raw("SELECT * FROM " + table + " WHERE 1 = 0").Rows()
+	if err != nil {
+		return nil, false
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, false
+	}
+
+	columns = map[string]bool{}
+	for _, name := range names {
+		columns[strings.ToLower(name)] = true
+	}
+
+	return columns, true
+}
+
+func (c *GormConnection) createVersionTableSQL() string {
+	dialect := c.DB.Dialector.Name()
+
+	columns := []string{
+		c.quote("date") + " " + dateType(dialect),
+		c.quote("version") + " " + varcharType(dialect),
+	}
+
+	for _, col := range c.columns {
+		columns = append(columns, c.quote(string(col))+" "+col.sqlType(dialect))
+	}
+
+	return "CREATE TABLE " + c.versionTable() + " (" + strings.Join(columns, ", ") + ")"
+}
+
+func dateType(dialect string) string {
+	switch dialect {
+	case "postgres":
+		return "TIMESTAMPTZ"
+	case "mysql":
+		return "DATETIME(3)"
+	case "sqlite":
+		return "DATETIME"
+	case "sqlserver":
+		return "DATETIMEOFFSET"
+	}
+
+	return "TIMESTAMP"
+}
@@ -0,0 +1,323 @@
+package migo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConvertSource is a migration tool which files can be converted.
+type ConvertSource string
+
+const (
+	// ConvertGolangMigrate converts `<version>_<name>.up.sql` and `.down.sql` files.
+	ConvertGolangMigrate ConvertSource = "golang-migrate"
+	// ConvertGoose converts `<version>_<name>.sql` files with `-- +goose Up/Down` sections.
+	ConvertGoose ConvertSource = "goose"
+	// ConvertDbmate converts `<version>_<name>.sql` files with `-- migrate:up/down` sections.
+	ConvertDbmate ConvertSource = "dbmate"
+)
+
+var (
+	golangMigrateFileRe = regexp.MustCompile(`^(\d+)_(.*)\.(up|down)\.sql$`)
+	singleFileRe        = regexp.MustCompile(`^(\d+)_(.*)\.sql$`)
+)
+
+// ConvertOptions configures conversion of migration files of other tool.
+type ConvertOptions struct {
+	Source ConvertSource
+	// SrcDir is the directory with migrations of the source tool.
+	SrcDir string
+	// DstDir is the directory where migo migrations are written.
+	DstDir string
+	// Mapping maps versions of the source, NumericVersionMapping by default.
+	Mapping VersionMapping
+	// DryRun only builds the report, nothing is written.
+	DryRun bool
+}
+
+// ConvertedMigration is a migration written by Convert.
+type ConvertedMigration struct {
+	Version  string
+	Sources  []string
+	UpFile   string
+	DownFile string
+}
+
+// ConvertProblem is something that Convert couldn't translate
+// or that needs to be checked by hand.
+type ConvertProblem struct {
+	File   string
+	Reason string
+}
+
+// ConvertReport describes result of Convert, migrations are in the order of versions.
+type ConvertReport struct {
+	Converted []ConvertedMigration
+	Problems  []ConvertProblem
+}
+
+// String returns human readable report.
+func (r *ConvertReport) String() string {
+	b := &strings.Builder{}
+	for _, c := range r.Converted {
+		fmt.Fprintf(b, "%s <- %s\n", c.Version, strings.Join(c.Sources, ", "))
+	}
+
+	for _, p := range r.Problems {
+		fmt.Fprintf(b, "! %s: %s\n", p.File, p.Reason)
+	}
+
+	return b.String()
+}
+
+type convertedFile struct {
+	foreign string
+	name    string
+	up      []byte
+	down    []byte
+	sources []string
+}
+
+// Convert rewrites migrations of other tool into migo
+// `<version>-<name>.up.sql` and `.down.sql` files.
+func Convert(opts ConvertOptions) (*ConvertReport, error) {
+	if opts.Mapping == nil {
+		opts.Mapping = NumericVersionMapping
+	}
+
+	entries, err := ioutil.ReadDir(opts.SrcDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ConvertReport{}
+	problem := func(file, format string, args ...interface{}) {
+		report.Problems = append(report.Problems, ConvertProblem{
+			File:   file,
+			Reason: fmt.Sprintf(format, args...),
+		})
+	}
+
+	files := map[string]*convertedFile{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		path := filepath.Join(opts.SrcDir, name)
+
+		if strings.HasSuffix(name, ".go") {
+			problem(name, "go migrations can't be converted, rewrite it as migo go migration")
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch opts.Source {
+		case ConvertGolangMigrate:
+			match := golangMigrateFileRe.FindStringSubmatch(name)
+			if match == nil {
+				problem(name, "unrecognized file name")
+				continue
+			}
+
+			f := files[match[1]]
+			if f == nil {
+				f = &convertedFile{foreign: match[1], name: match[2]}
+				files[match[1]] = f
+			}
+
+			if match[3] == "up" {
+				f.up = data
+			} else {
+				f.down = data
+			}
+			f.sources = append(f.sources, name)
+		case ConvertGoose, ConvertDbmate:
+			match := singleFileRe.FindStringSubmatch(name)
+			if match == nil {
+				problem(name, "unrecognized file name")
+				continue
+			}
+
+			if _, found := files[match[1]]; found {
+				problem(name, "version %s is used by many files", match[1])
+				continue
+			}
+
+			up, down, problems := splitSections(opts.Source, data)
+			for _, p := range problems {
+				problem(name, p)
+			}
+
+			files[match[1]] = &convertedFile{
+				foreign: match[1],
+				name:    match[2],
+				up:      up,
+				down:    down,
+				sources: []string{name},
+			}
+		default:
+			return nil, fmt.Errorf("unknown convert source '%s'", opts.Source)
+		}
+	}
+
+	foreignVersions := make([]string, 0, len(files))
+	for v := range files {
+		foreignVersions = append(foreignVersions, v)
+	}
+	sort.Slice(foreignVersions, func(i, j int) bool {
+		left, _ := strconv.ParseUint(foreignVersions[i], 10, 64)
+		right, _ := strconv.ParseUint(foreignVersions[j], 10, 64)
+		return left < right
+	})
+
+	var prev *Version
+	written := map[string]string{}
+	for _, foreign := range foreignVersions {
+		f := files[foreign]
+		source := strings.Join(f.sources, ", ")
+
+		mapped, err := opts.Mapping(foreign)
+		if err != nil {
+			problem(source, "%v", err)
+			continue
+		}
+
+		v, err := VersionFromString(mapped + "-" + strings.Replace(f.name, " ", "-", -1))
+		if err != nil {
+			problem(source, "%v", err)
+			continue
+		}
+
+		if other, found := written[v.StringWithoutName()]; found {
+			problem(source, "version %s is already used by %s", v.StringWithoutName(), other)
+			continue
+		}
+		written[v.StringWithoutName()] = source
+
+		if prev != nil && !v.GreaterThan(prev) {
+			problem(source, "version %s breaks order of migrations, use other version mapping", v.StringWithoutName())
+		}
+		prev = v
+
+		if f.up == nil {
+			problem(source, "up migration is missing, empty one is created")
+			f.up = []byte{}
+		}
+
+		if f.down == nil {
+			problem(source, "down migration is missing, empty one is created")
+			f.down = []byte{}
+		}
+
+		converted := ConvertedMigration{
+			Version:  v.String(),
+			Sources:  f.sources,
+			UpFile:   filepath.Join(opts.DstDir, v.String()+".up.sql"),
+			DownFile: filepath.Join(opts.DstDir, v.String()+".down.sql"),
+		}
+
+		if !opts.DryRun {
+			err = os.MkdirAll(opts.DstDir, os.ModePerm)
+			if err != nil {
+				return report, err
+			}
+
+			err = ioutil.WriteFile(converted.UpFile, f.up, 0644)
+			if err != nil {
+				return report, err
+			}
+
+			err = ioutil.WriteFile(converted.DownFile, f.down, 0644)
+			if err != nil {
+				return report, err
+			}
+		}
+
+		report.Converted = append(report.Converted, converted)
+	}
+
+	return report, nil
+}
+
+// splitSections splits goose or dbmate file into up and down parts,
+// annotations are removed. Returns descriptions of things
+// that can't be translated.
+func splitSections(source ConvertSource, data []byte) (up, down []byte, problems []string) {
+	var upMarker, downMarker, prefix string
+	switch source {
+	case ConvertGoose:
+		upMarker, downMarker, prefix = "-- +goose up", "-- +goose down", "-- +goose "
+	case ConvertDbmate:
+		upMarker, downMarker, prefix = "-- migrate:up", "-- migrate:down", "-- migrate:"
+	}
+
+	var current *bytes.Buffer
+	upBuf, downBuf := &bytes.Buffer{}, &bytes.Buffer{}
+	foundUp, foundDown, blocks := false, false, false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lower := strings.ToLower(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(lower, upMarker):
+			current, foundUp = upBuf, true
+			if options := strings.TrimSpace(lower[len(upMarker):]); options != "" {
+				problems = append(problems, fmt.Sprintf("options '%s' are ignored, migo runs migration in a transaction", options))
+			}
+			continue
+		case strings.HasPrefix(lower, downMarker):
+			current, foundDown = downBuf, true
+			if options := strings.TrimSpace(lower[len(downMarker):]); options != "" {
+				problems = append(problems, fmt.Sprintf("options '%s' are ignored, migo runs migration in a transaction", options))
+			}
+			continue
+		case strings.HasPrefix(lower, "-- +goose statementbegin"), strings.HasPrefix(lower, "-- +goose statementend"):
+			blocks = true
+			continue
+		case strings.HasPrefix(lower, prefix):
+			problems = append(problems, fmt.Sprintf("annotation '%s' is not supported", strings.TrimSpace(line)))
+			continue
+		}
+
+		if current == nil {
+			if lower != "" && !strings.HasPrefix(lower, "--") {
+				problems = append(problems, "statements before the first section are dropped")
+			}
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+
+	if blocks {
+		problems = append(problems, "StatementBegin/StatementEnd blocks are removed, check that statements are split correctly")
+	}
+
+	if foundUp {
+		up = upBuf.Bytes()
+	}
+
+	if foundDown {
+		down = downBuf.Bytes()
+	}
+
+	return up, down, problems
+}
@@ -0,0 +1,133 @@
+package migo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConvertFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "convert")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestConvertGolangMigrate(t *testing.T) {
+	src := writeConvertFiles(t, map[string]string{
+		"0002_add_email.up.sql":      "ALTER TABLE users ADD email TEXT;",
+		"0002_add_email.down.sql":    "ALTER TABLE users DROP email;",
+		"0001_create_users.up.sql":   "CREATE TABLE users (id INT);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+		"0010_add_phone.up.sql":      "ALTER TABLE users ADD phone TEXT;",
+	})
+	defer os.RemoveAll(src)
+	dst := filepath.Join(src, "migo")
+
+	report, err := Convert(ConvertOptions{Source: ConvertGolangMigrate, SrcDir: src, DstDir: dst})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "1-create_users <- 0001_create_users.down.sql, 0001_create_users.up.sql\n" +
+		"2-add_email <- 0002_add_email.down.sql, 0002_add_email.up.sql\n" +
+		"10-add_phone <- 0010_add_phone.up.sql\n" +
+		"! 0010_add_phone.up.sql: down migration is missing, empty one is created\n"
+	if report.String() != expected {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dst, "2-add_email.down.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "ALTER TABLE users DROP email;" {
+		t.Errorf("unexpected content '%s'", data)
+	}
+
+	ms, err := NewSQLMigrationLoader(dst).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ms) != 3 {
+		t.Errorf("expected 3 migrations, got %d", len(ms))
+	}
+}
+
+func TestConvertGoose(t *testing.T) {
+	src := writeConvertFiles(t, map[string]string{
+		"20200102150405_create_users.sql": `-- comment
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (id INT);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE users;
+`,
+		"20200103150405_seed.go": "package migrations",
+	})
+	defer os.RemoveAll(src)
+	dst := filepath.Join(src, "migo")
+
+	report, err := Convert(ConvertOptions{
+		Source:  ConvertGoose,
+		SrcDir:  src,
+		DstDir:  dst,
+		Mapping: TimestampVersionMapping,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Converted) != 1 || report.Converted[0].Version != "1.0.0.1577977445-create_users" {
+		t.Fatalf("unexpected report:\n%s", report)
+	}
+
+	if len(report.Problems) != 2 {
+		t.Errorf("expected 2 problems, got:\n%s", report)
+	}
+
+	up, _ := ioutil.ReadFile(report.Converted[0].UpFile)
+	if string(up) != "CREATE TABLE users (id INT);\n\n" {
+		t.Errorf("unexpected up '%s'", up)
+	}
+
+	down, _ := ioutil.ReadFile(report.Converted[0].DownFile)
+	if string(down) != "DROP TABLE users;\n" {
+		t.Errorf("unexpected down '%s'", down)
+	}
+}
+
+func TestConvertDbmateDryRun(t *testing.T) {
+	src := writeConvertFiles(t, map[string]string{
+		"20200102150405_create_users.sql": "-- migrate:up transaction:false\nCREATE TABLE users (id INT);\n-- migrate:down\nDROP TABLE users;\n",
+	})
+	defer os.RemoveAll(src)
+	dst := filepath.Join(src, "migo")
+
+	report, err := Convert(ConvertOptions{Source: ConvertDbmate, SrcDir: src, DstDir: dst, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Converted) != 1 || len(report.Problems) != 1 {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Error("dry run should not write files")
+	}
+}
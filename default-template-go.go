@@ -4,13 +4,14 @@ var GoDefaultTemplateData = `// file generated with ./migo/tmpl/go/tmpl.go, feel
 package yourpackagename
 
 import (
-	"github.com/jinzhu/gorm"
 	"github.com/walkline/migo"
 	"github.com/walkline/migo/connections/gormconnection"
+	"gorm.io/gorm"{{range .imports}}
+	{{.}}{{end}}
 )
 
 func init() {
-	migo.DefaultGoMigrationLoader.Add(&Migration{{.version.verSafe}}{})
+	{{if .set}}migo.Set("{{.set}}").Add(&Migration{{.version.verSafe}}{}){{else}}migo.DefaultGoMigrationLoader.Add(&Migration{{.version.verSafe}}{}){{end}}
 }
 
 // Migration{{.version.verSafe}} implements {{.version.ver}}-{{.version.name}} migration :)
@@ -19,16 +20,16 @@ type Migration{{.version.verSafe}} struct{
 }
 
 func (m *Migration{{.version.verSafe}}) Up() error {
-	// m.DB ...
+{{with .up}}{{.}}{{else}}	// m.DB ...
 
 	return nil
-}
+{{end}}}
 
 func (m *Migration{{.version.verSafe}}) Down() error {
-	// m.DB ...
+{{with .down}}{{.}}{{else}}	// m.DB ...
 
 	return nil
-}
+{{end}}}
 
 func (m *Migration{{.version.verSafe}}) Version() migo.Version {
 	v, err := migo.VersionFromString("{{.version.ver}}-{{.version.name}}")
@@ -1,7 +1,14 @@
 package migo
 
 var SQLUpDefaultTemplateData = `-- UP: {{.version.name}}
-`
+{{.up}}`
 
 var SQLDownDefaultTemplateData = `-- DOWN: {{.version.name}}
-`
+{{.down}}`
+
+var SQLSingleDefaultTemplateData = `-- migo:up
+-- UP: {{.version.name}}
+{{.up}}
+-- migo:down
+-- DOWN: {{.version.name}}
+{{.down}}`
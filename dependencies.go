@@ -0,0 +1,212 @@
+package migo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrDependencyCycle is returned when migrations depend on each other.
+	ErrDependencyCycle = errors.New("dependency cycle")
+	// ErrMissingDependency is returned when dependency is neither loaded nor applied.
+	ErrMissingDependency = errors.New("missing dependency")
+)
+
+// Dependent is implemented by migrations that have to be applied after
+// other migrations, e.g. `[]string{"1.4.0-add-users"}`. Versions can be
+// given without name. Dependent migration is applied when it is missing
+// in the database even if its version is lower than the applied one.
+type Dependent interface {
+	Depends() []string
+}
+
+func migrationDependencies(migration Migration) []string {
+	d, ok := unwrapMigration(migration).(Dependent)
+	if !ok {
+		return nil
+	}
+
+	return d.Depends()
+}
+
+// dependencyGraph resolves dependencies of loaded migrations.
+type dependencyGraph struct {
+	byVersion map[string]Migration
+	applied   map[string]bool
+	deps      map[Migration][]Migration
+}
+
+// newDependencyGraph resolves dependencies of `ms`, dependencies that
+// are only applied (e.g. archived by Squash) are satisfied and skipped,
+// dependencies replaced by a Superseder are satisfied by it.
+// Returns ErrMissingDependency or ErrDependencyCycle.
+func newDependencyGraph(ms []Migration, applied []Version) (*dependencyGraph, error) {
+	g := &dependencyGraph{
+		byVersion: map[string]Migration{},
+		applied:   map[string]bool{},
+		deps:      map[Migration][]Migration{},
+	}
+
+	for _, migration := range ms {
+		g.byVersion[migration.Version().StringWithoutName()] = migration
+	}
+
+	for _, v := range applied {
+		g.applied[v.StringWithoutName()] = true
+	}
+
+	for _, migration := range ms {
+		for _, dep := range migrationDependencies(migration) {
+			v, err := versionWithOptionalName(dep)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' has bad dependency '%s': %w", migration.Version(), dep, err)
+			}
+
+			depMigration, found := g.byVersion[v.StringWithoutName()]
+			if found && v.Name != "" && depMigration.Version().Name != v.Name {
+				found = false
+			}
+
+			if !found {
+				depMigration, found = supersederOf(ms, v)
+			}
+
+			if found {
+				g.deps[migration] = append(g.deps[migration], depMigration)
+				continue
+			}
+
+			if !g.applied[v.StringWithoutName()] {
+				return nil, fmt.Errorf("%w: '%s' depends on '%s'", ErrMissingDependency, migration.Version(), dep)
+			}
+		}
+	}
+
+	return g, g.checkCycles(ms)
+}
+
+// supersederOf returns migration of `ms` that supersedes version `v`,
+// the one with the lowest superseded version is returned when there are many.
+func supersederOf(ms []Migration, v *Version) (Migration, bool) {
+	var (
+		result Migration
+		lowest *Version
+	)
+
+	for _, migration := range ms {
+		s, ok := unwrapMigration(migration).(Superseder)
+		if !ok || s.Supersedes() == "" {
+			continue
+		}
+
+		until, err := versionWithOptionalName(s.Supersedes())
+		if err != nil || !until.GreaterThanOrEqual(v) {
+			continue
+		}
+
+		if lowest == nil || lowest.GreaterThan(until) {
+			result, lowest = migration, until
+		}
+	}
+
+	return result, result != nil
+}
+
+func (g *dependencyGraph) checkCycles(ms []Migration) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := map[Migration]int{}
+	path := []Migration{}
+
+	var visit func(m Migration) error
+	visit = func(m Migration) error {
+		switch state[m] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := []string{}
+			for i := len(path) - 1; i >= 0; i-- {
+				cycle = append([]string{path[i].Version().String()}, cycle...)
+				if path[i] == m {
+					break
+				}
+			}
+			cycle = append(cycle, m.Version().String())
+
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+		}
+
+		state[m] = visiting
+		path = append(path, m)
+		for _, dep := range g.deps[m] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[m] = visited
+
+		return nil
+	}
+
+	for _, m := range ms {
+		if err := visit(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// order returns `pending` migrations sorted by version in the order
+// where every migration follows its dependencies. Dependencies have to
+// be either applied or pending.
+func (g *dependencyGraph) order(pending []Migration, applied []Version) ([]Migration, error) {
+	appliedVersions := map[string]bool{}
+	for _, v := range applied {
+		appliedVersions[v.String()] = true
+	}
+
+	isPending := map[Migration]bool{}
+	for _, m := range pending {
+		isPending[m] = true
+	}
+
+	for _, m := range pending {
+		for _, dep := range g.deps[m] {
+			if !isPending[dep] && !appliedVersions[dep.Version().String()] {
+				return nil, fmt.Errorf("%w: '%s' depends on '%s' that is not applied", ErrMissingDependency, m.Version(), dep.Version())
+			}
+		}
+	}
+
+	ordered := make([]Migration, 0, len(pending))
+	done := map[Migration]bool{}
+	for len(ordered) < len(pending) {
+		for _, m := range pending {
+			if done[m] || !g.ready(m, isPending, done) {
+				continue
+			}
+
+			ordered = append(ordered, m)
+			done[m] = true
+			break
+		}
+	}
+
+	return ordered, nil
+}
+
+func (g *dependencyGraph) ready(m Migration, isPending, done map[Migration]bool) bool {
+	for _, dep := range g.deps[m] {
+		if isPending[dep] && !done[dep] {
+			return false
+		}
+	}
+
+	return true
+}
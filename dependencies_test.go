@@ -0,0 +1,136 @@
+package migo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+type DependentMigrationMock struct {
+	c    Connection
+	v    Version
+	deps []string
+}
+
+func (m *DependentMigrationMock) SetConnection(c Connection) { m.c = c }
+func (m *DependentMigrationMock) Version() Version           { return m.v }
+func (m *DependentMigrationMock) SetVersion(v *Version)      { m.v = *v }
+func (m *DependentMigrationMock) Up() error                  { return m.c.Exec("UP " + m.v.String()) }
+func (m *DependentMigrationMock) Down() error                { return m.c.Exec("DOWN " + m.v.String()) }
+func (m *DependentMigrationMock) Depends() []string          { return m.deps }
+
+func newDependentMigrate(c Connection, deps map[string][]string) *Migrate {
+	m := NewMigrate(c)
+	m.SetOutput(ioutil.Discard)
+	for v, d := range deps {
+		m.Add(&DependentMigrationMock{v: mustVersion(v), deps: d})
+	}
+
+	return m
+}
+
+func TestUpToLatestAppliesMergedDependent(t *testing.T) {
+	c := &HistoryConnectionMock{versions: []string{"1-users", "3-orders"}}
+	m := newDependentMigrate(c, map[string][]string{
+		"1-users":  nil,
+		"2-emails": {"1.0.0-users"},
+		"3-orders": nil,
+		"4-items":  {"3"},
+	})
+
+	err := m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.sqls) != 2 || c.sqls[0] != "UP 2-emails" || c.sqls[1] != "UP 4-items" {
+		t.Errorf("unexpected order %q", c.sqls)
+	}
+}
+
+func TestUpToLatestTopologicalOrder(t *testing.T) {
+	c := &HistoryConnectionMock{}
+	m := newDependentMigrate(c, map[string][]string{
+		"1-users":  nil,
+		"2-emails": {"3-phones"},
+		"3-phones": {"1"},
+	})
+
+	err := m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.sqls) != 3 || c.sqls[0] != "UP 1-users" || c.sqls[1] != "UP 3-phones" || c.sqls[2] != "UP 2-emails" {
+		t.Errorf("unexpected order %q", c.sqls)
+	}
+}
+
+func TestUpToLatestDependencyErrors(t *testing.T) {
+	m := newDependentMigrate(&HistoryConnectionMock{}, map[string][]string{
+		"1-users":  {"3"},
+		"2-emails": {"1"},
+		"3-phones": {"2"},
+	})
+
+	err := m.UpToLatest()
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("expected ErrDependencyCycle, got %v", err)
+	}
+
+	m = newDependentMigrate(&HistoryConnectionMock{}, map[string][]string{
+		"2-emails": {"1-users"},
+	})
+
+	err = m.UpToLatest()
+	if !errors.Is(err, ErrMissingDependency) {
+		t.Errorf("expected ErrMissingDependency, got %v", err)
+	}
+
+	m = newDependentMigrate(&HistoryConnectionMock{}, map[string][]string{
+		"1-users":  nil,
+		"2-emails": {"1-accounts"},
+	})
+
+	err = m.UpToLatest()
+	if !errors.Is(err, ErrMissingDependency) {
+		t.Errorf("dependency with other name should be missing, got %v", err)
+	}
+}
+
+func TestSQLMigrationDepends(t *testing.T) {
+	ioutil.WriteFile("2-name.sql", []byte("-- migo:depends 1.4.0-add-users 1.5.0\n-- migo:depends 1.6.0\n-- migo:up\nSELECT 1;\n-- migo:down\n"), 0644)
+	defer removeFiles("2-name.sql")
+
+	deps := (&SQLMigration{Path: "2-name.sql"}).Depends()
+	if len(deps) != 3 || deps[0] != "1.4.0-add-users" || deps[2] != "1.6.0" {
+		t.Errorf("unexpected dependencies %v", deps)
+	}
+}
+
+func TestUpToLatestSquashedDependency(t *testing.T) {
+	dir, err := ioutil.TempDir("", "depends")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	until := mustVersion("1.4.0-squashed")
+	ioutil.WriteFile(path.Join(dir, "1.4.0-squashed.sql"), []byte(baselineContent(&until, "CREATE TABLE users (id INT);")), 0644)
+	ioutil.WriteFile(path.Join(dir, "2.0.0-orders.sql"), []byte("-- migo:depends 1.4.0-add-users\n-- migo:up\nCREATE TABLE orders (id INT);\n-- migo:down\nDROP TABLE orders;\n"), 0644)
+
+	c := &HistoryConnectionMock{}
+	m := NewMigrate(c, NewSQLMigrationLoader(dir))
+	m.SetOutput(ioutil.Discard)
+
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.sqls) != 2 || c.sqls[0] != "CREATE TABLE users (id INT);" || c.sqls[1] != "CREATE TABLE orders (id INT);" {
+		t.Errorf("dependent migration should follow baseline, got %q", c.sqls)
+	}
+}
@@ -0,0 +1,130 @@
+package migo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DirectivePrefix starts directives of sql migrations,
+// e.g. `-- migo:irreversible`.
+const DirectivePrefix = "-- migo:"
+
+const (
+	directiveIrreversible = "irreversible"
+	directiveSupersedes   = "supersedes"
+	directiveDepends      = "depends"
+)
+
+// directive is a `-- migo:<name> <args>` line of sql migration.
+type directive struct {
+	name string
+	args []string
+	line int
+}
+
+// parseDirective parses line as a directive, name is lower cased.
+func parseDirective(line string) (directive, bool) {
+	line = strings.TrimSpace(line)
+	if len(line) < len(DirectivePrefix) || !strings.EqualFold(line[:len(DirectivePrefix)], DirectivePrefix) {
+		return directive{}, false
+	}
+
+	fields := strings.Fields(line[len(DirectivePrefix):])
+	if len(fields) == 0 {
+		return directive{}, false
+	}
+
+	return directive{
+		name: strings.ToLower(fields[0]),
+		args: fields[1:],
+	}, true
+}
+
+// sqlHeader is what Load reads from sql migration files once:
+// directives and whether single file has down section.
+type sqlHeader struct {
+	directives []directive
+	hasDown    bool
+}
+
+// readSQLHeader streams file line by line and returns directives of its
+// leading comments, they end at the first statement. Single files are
+// read to the end to find `-- migo:down` marker, statements aren't kept.
+func readSQLHeader(path string, single bool) (*sqlHeader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := &sqlHeader{}
+	inHeader, hasUp := true, false
+	r := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := readLine(r)
+		if err == io.EOF && line == "" {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		d, ok := parseDirective(line)
+		switch {
+		case ok && single && d.name == "up":
+			hasUp = true
+		case ok && single && d.name == "down":
+			h.hasDown = true
+			return h, nil
+		case ok && inHeader:
+			d.line = n
+			h.directives = append(h.directives, d)
+		case !ok && inHeader:
+			trimmed := strings.TrimSpace(line)
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				if single && !hasUp {
+					return nil, fmt.Errorf("%s: line %d: statement before '%s'", path, n, SQLUpMarker)
+				}
+				inHeader = false
+				if !single {
+					return h, nil
+				}
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if single && !hasUp {
+		return nil, fmt.Errorf("%s: %w", path, errNoUpSection)
+	}
+
+	return h, nil
+}
+
+// readLine returns the next line without line break, only the beginning
+// of long lines is returned, directives are short.
+func readLine(r *bufio.Reader) (string, error) {
+	data, err := r.ReadSlice('\n')
+	line := strings.TrimRight(string(data), "\r\n")
+	for err == bufio.ErrBufferFull {
+		_, err = r.ReadSlice('\n')
+	}
+
+	return line, err
+}
+
+func hasDirective(ds []directive, name string) bool {
+	for _, d := range ds {
+		if d.name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package migo
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestReadSQLHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "header")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	long := "INSERT INTO users VALUES ('" + strings.Repeat("x", 10000) + "');\n"
+	single := path.Join(dir, "1-a.sql")
+	ioutil.WriteFile(single, []byte("-- migo:depends 0\n\n-- migo:up\n-- migo:tags dev\n"+long+"-- migo:irreversible\n-- migo:down\nDELETE FROM users;\n"), 0644)
+
+	h, err := readSQLHeader(single, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.directives) != 2 || h.directives[0].name != directiveDepends || h.directives[1].name != directiveTags || !h.hasDown {
+		t.Errorf("unexpected header %+v", h)
+	}
+
+	split := path.Join(dir, "2-b.up.sql")
+	ioutil.WriteFile(split, []byte(long+"-- migo:irreversible\n"), 0644)
+
+	h, err = readSQLHeader(split, false)
+	if err != nil || len(h.directives) != 0 {
+		t.Errorf("directives after statements should be ignored: %+v %v", h, err)
+	}
+
+	broken := path.Join(dir, "3-c.sql")
+	ioutil.WriteFile(broken, []byte("DELETE FROM users;\n-- migo:up\n"), 0644)
+
+	_, err = readSQLHeader(broken, true)
+	if err == nil {
+		t.Error("statement before up section should fail")
+	}
+}
+
+func TestSQLMigrationReadsDirectivesOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "header")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(path.Join(dir, "1-a.sql"), []byte("-- migo:depends 0\n-- migo:up\nSELECT 1;\n"), 0644)
+
+	loader := NewSQLMigrationLoader(dir)
+	loader.SetAllowMissingDown(true)
+	migs, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Remove(path.Join(dir, "1-a.sql"))
+
+	m := migs[0].(*SQLMigration)
+	if len(m.Depends()) != 1 || !m.Irreversible() {
+		t.Errorf("directives should be cached by Load: %q %v", m.Depends(), m.Irreversible())
+	}
+
+	missing := &SQLMigration{UpPath: path.Join(dir, "2-b.up.sql")}
+	missing.SetConnection(&ConnectionMock{})
+	if len(missing.Depends()) != 0 || missing.Up() == nil {
+		t.Error("read error should be returned by Up")
+	}
+}
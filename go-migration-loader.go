@@ -1,7 +1,8 @@
 package migo
 
 type GoMigrationLoader struct {
-	m []Migration
+	m     []Migration
+	seeds []goSeed
 }
 
 var DefaultGoMigrationLoader = &GoMigrationLoader{
@@ -18,4 +19,5 @@ func (l *GoMigrationLoader) Load() ([]Migration, error) {
 
 func (l *GoMigrationLoader) Clear() {
 	l.m = []Migration{}
+	l.seeds = nil
 }
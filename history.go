@@ -0,0 +1,144 @@
+package migo
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/walkline/migo/internal/osuser"
+)
+
+// GitCommitEnv is environment variable that is used as git commit
+// in history when it is not set with Migrate.SetGitCommit.
+const GitCommitEnv = "MIGO_GIT_COMMIT"
+
+var ErrHistoryNotSupported = errors.New("connection doesn't support history")
+
+// HistoryEvent is a kind of operation recorded in history.
+type HistoryEvent string
+
+const (
+	// HistoryUp is recorded when migration is applied.
+	HistoryUp HistoryEvent = "up"
+	// HistoryDown is recorded when migration is discarded.
+	HistoryDown HistoryEvent = "down"
+	// HistoryForce is recorded when version is marked as applied without running migration.
+	HistoryForce HistoryEvent = "force"
+	// HistoryRepair is recorded when version is removed without running migration.
+	HistoryRepair HistoryEvent = "repair"
+	// HistoryBaseline is recorded when migration is marked as applied by Baseline.
+	HistoryBaseline HistoryEvent = "baseline"
+	// HistorySupersede is recorded when baseline made by Squash is marked
+	// as applied, because migrations it supersedes are applied.
+	HistorySupersede HistoryEvent = "supersede"
+)
+
+// HistoryOutcome is a result of recorded operation.
+type HistoryOutcome string
+
+const (
+	HistorySuccess HistoryOutcome = "success"
+	HistoryFailure HistoryOutcome = "failure"
+)
+
+// HistoryEntry is a single record of migration history.
+type HistoryEntry struct {
+	Version   string
+	Event     HistoryEvent
+	StartedAt time.Time
+	Duration  time.Duration
+	User      string
+	Hostname  string
+	GitCommit string
+	Outcome   HistoryOutcome
+	Error     string
+}
+
+// HistoryConnection is implemented by connections that keep
+// append-only history of all operations with migrations.
+type HistoryConnection interface {
+	AppendHistory(e HistoryEntry) error
+	// LoadHistory returns entries in the order they were appended.
+	LoadHistory() ([]HistoryEntry, error)
+}
+
+// SetGitCommit sets git commit that is recorded in history,
+// by default it is taken from MIGO_GIT_COMMIT environment variable.
+func (m *Migrate) SetGitCommit(commit string) {
+	m.gitCommit = commit
+}
+
+// History returns all recorded operations with migrations.
+// Returns ErrHistoryNotSupported when connection doesn't implement HistoryConnection.
+func (m *Migrate) History() ([]HistoryEntry, error) {
+	h, ok := m.c.(HistoryConnection)
+	if !ok {
+		return nil, ErrHistoryNotSupported
+	}
+
+	return h.LoadHistory()
+}
+
+// Force marks version as applied without running migration.
+func (m *Migrate) Force(version string) error {
+	_, err := VersionFromString(version)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = m.c.SetVersion(version)
+
+	return m.recordHistory(version, HistoryForce, start, err)
+}
+
+// Repair removes version from applied ones without running migration,
+// e.g. when changes of a migration were reverted by hand.
+func (m *Migrate) Repair(version string) error {
+	_, err := VersionFromString(version)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = m.removeVersion(version)
+
+	return m.recordHistory(version, HistoryRepair, start, err)
+}
+
+// recordHistory appends entry to history if connection supports it.
+// Returns `opErr` when it is not nil, otherwise error of writing history.
+func (m *Migrate) recordHistory(version string, event HistoryEvent, start time.Time, opErr error) error {
+	h, ok := m.c.(HistoryConnection)
+	if !ok {
+		return opErr
+	}
+
+	e := HistoryEntry{
+		Version:   version,
+		Event:     event,
+		StartedAt: start,
+		Duration:  time.Since(start),
+		User:      osuser.Name(),
+		GitCommit: m.gitCommit,
+		Outcome:   HistorySuccess,
+	}
+
+	e.Hostname, _ = os.Hostname()
+
+	if e.GitCommit == "" {
+		e.GitCommit = os.Getenv(GitCommitEnv)
+	}
+
+	if opErr != nil {
+		e.Outcome = HistoryFailure
+		e.Error = opErr.Error()
+	}
+
+	err := h.AppendHistory(e)
+	if opErr != nil {
+		return opErr
+	}
+
+	return err
+}
@@ -0,0 +1,139 @@
+package migo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type HistoryConnectionMock struct {
+	ConnectionMock
+	versions []string
+	history  []HistoryEntry
+}
+
+func (c *HistoryConnectionMock) LoadVersions() ([]string, error) {
+	if len(c.versions) == 0 {
+		return []string{"0-null"}, nil
+	}
+
+	return c.versions, nil
+}
+
+func (c *HistoryConnectionMock) SetVersion(v string) error {
+	c.versions = append(c.versions, v)
+	return nil
+}
+
+func (c *HistoryConnectionMock) RemoveVersion(v string) error {
+	for i, ver := range c.versions {
+		if ver == v {
+			c.versions = append(c.versions[:i], c.versions[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.New("version not found")
+}
+
+func (c *HistoryConnectionMock) AppendHistory(e HistoryEntry) error {
+	c.history = append(c.history, e)
+	return nil
+}
+
+func (c *HistoryConnectionMock) LoadHistory() ([]HistoryEntry, error) {
+	return c.history, nil
+}
+
+func (c *HistoryConnectionMock) Tx() (Transaction, error) {
+	return c, nil
+}
+
+func TestHistory(t *testing.T) {
+	os.Setenv(GitCommitEnv, "abc123")
+	defer os.Unsetenv(GitCommitEnv)
+
+	ioutil.WriteFile("1-name.up.sql", []byte("UP SQL 1;"), 0644)
+	ioutil.WriteFile("1-name.down.sql", []byte("DOWN SQL 1;"), 0644)
+	defer removeFiles("1-name.up.sql", "1-name.down.sql")
+
+	c := &HistoryConnectionMock{}
+
+	m := Migrate{}
+	m.SetConncetion(c)
+	m.SetOutput(ioutil.Discard)
+
+	m1 := &SQLMigration{UpPath: "1-name.up.sql", DownPath: "1-name.down.sql"}
+	v, _ := VersionFromString("1-name")
+	m1.SetVersion(v)
+	m.Add(m1)
+
+	m2 := &SQLMigration{UpPath: "not-exists.up.sql"}
+	v, _ = VersionFromString("2-name")
+	m2.SetVersion(v)
+	m.Add(m2)
+
+	if m.UpToLatest() == nil {
+		t.Fatal("expected error of the second migration")
+	}
+
+	err := m.DownWithSteps(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Force("1-name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Repair("1-name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.versions) != 0 {
+		t.Error("unexpected versions", c.versions)
+	}
+
+	history, err := m.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		version string
+		event   HistoryEvent
+		outcome HistoryOutcome
+	}{
+		{"1-name", HistoryUp, HistorySuccess},
+		{"2-name", HistoryUp, HistoryFailure},
+		{"1-name", HistoryDown, HistorySuccess},
+		{"1-name", HistoryForce, HistorySuccess},
+		{"1-name", HistoryRepair, HistorySuccess},
+	}
+
+	if len(history) != len(expected) {
+		t.Fatal("unexpected history", history)
+	}
+
+	for i, e := range expected {
+		h := history[i]
+		if h.Version != e.version || h.Event != e.event || h.Outcome != e.outcome || h.GitCommit != "abc123" {
+			t.Errorf("expected: %v; actual: %+v", e, h)
+		}
+	}
+
+	if history[1].Error == "" {
+		t.Error("error of failed migration should be recorded")
+	}
+}
+
+func TestHistoryNotSupported(t *testing.T) {
+	m := NewMigrate(&ConnectionMock{})
+	_, err := m.History()
+	if err != ErrHistoryNotSupported {
+		t.Error("expected", ErrHistoryNotSupported, "have", err)
+	}
+}
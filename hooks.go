@@ -0,0 +1,225 @@
+package migo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/walkline/migo/sqlscanner"
+)
+
+// Direction is a direction of migration.
+type Direction string
+
+const (
+	DirectionUp   Direction = "up"
+	DirectionDown Direction = "down"
+)
+
+// HookPoint is a moment of migration process when hooks run.
+type HookPoint string
+
+const (
+	// HookBeforeAll runs once before the first migration, only when there is something to apply.
+	HookBeforeAll HookPoint = "before_all"
+	// HookAfterAll runs once after all migrations succeeded.
+	HookAfterAll HookPoint = "after_all"
+	// HookBeforeEach runs before every migration.
+	HookBeforeEach HookPoint = "before_each"
+	// HookAfterEach runs after every succeeded migration.
+	HookAfterEach HookPoint = "after_each"
+	// HookOnError runs when migration or another hook fails.
+	HookOnError HookPoint = "on_error"
+)
+
+// HookPoints are all hook points in the order they happen.
+var HookPoints = []HookPoint{HookBeforeAll, HookBeforeEach, HookAfterEach, HookAfterAll, HookOnError}
+
+// Hook runs around migrations, `v` is nil for HookBeforeAll and HookAfterAll.
+// Error of hook stops migration process.
+type Hook func(v *Version, d Direction, c Connection) error
+
+// ErrorHook runs when migration or hook fails with `err`, `v` is nil
+// when HookBeforeAll or HookAfterAll failed. Its error is only printed.
+type ErrorHook func(v *Version, d Direction, c Connection, err error) error
+
+// Hooks are go functions that run around migrations, see SetHooks.
+type Hooks struct {
+	BeforeAll  Hook
+	AfterAll   Hook
+	BeforeEach Hook
+	AfterEach  Hook
+	OnError    ErrorHook
+}
+
+func (h Hooks) hook(p HookPoint) Hook {
+	switch p {
+	case HookBeforeAll:
+		return h.BeforeAll
+	case HookAfterAll:
+		return h.AfterAll
+	case HookBeforeEach:
+		return h.BeforeEach
+	case HookAfterEach:
+		return h.AfterEach
+	}
+
+	return nil
+}
+
+// SQLHook is a sql file that runs at the hook point, e.g. `_before_each.sql`
+// in the directory of migrations. Version and direction of migration
+// are available in the file as `${migo_version}` and `${migo_direction}`.
+type SQLHook struct {
+	Point     HookPoint
+	Path      string
+	Dialect   sqlscanner.Dialect
+	Variables map[string]string
+}
+
+func (h SQLHook) run(v *Version, d Direction, c Connection) error {
+	ver := ""
+	if v != nil {
+		ver = v.String()
+	}
+
+	script := &SQLMigration{
+		UpPath:  h.Path,
+		Dialect: h.Dialect,
+		Variables: MergeVariables(h.Variables, map[string]string{
+			"migo_version":   ver,
+			"migo_direction": string(d),
+		}),
+	}
+	script.SetConnection(c)
+
+	err := script.Up()
+	if err != nil {
+		return fmt.Errorf("hook '%s': %w", h.Path, err)
+	}
+
+	return nil
+}
+
+// HookLoader is implemented by loaders that load sql hooks
+// next to migrations, e.g. SQLMigrationsLoader.
+type HookLoader interface {
+	LoadHooks() ([]SQLHook, error)
+}
+
+// LoadHooks loads `_before_all.sql`, `_after_all.sql`, `_before_each.sql`,
+// `_after_each.sql` and `_on_error.sql` files of the directory.
+func (l *SQLMigrationsLoader) LoadHooks() ([]SQLHook, error) {
+	hooks := []SQLHook{}
+	for _, p := range HookPoints {
+		path := filepath.Join(l.path, "_"+string(p)+".sql")
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		hooks = append(hooks, SQLHook{
+			Point:     p,
+			Path:      path,
+			Dialect:   l.dialect,
+			Variables: l.variables,
+		})
+	}
+
+	return hooks, nil
+}
+
+// SetHooks sets go hooks, they run before sql hooks of loaders.
+func (m *Migrate) SetHooks(h Hooks) {
+	m.hooks = h
+}
+
+// runHooks runs go and sql hooks of the point.
+func (m *Migrate) runHooks(p HookPoint, v *Version, d Direction) error {
+	if h := m.hooks.hook(p); h != nil {
+		err := h(v, d, m.c)
+		if err != nil {
+			return fmt.Errorf("%s hook: %w", p, err)
+		}
+	}
+
+	for _, h := range m.sqlHooks {
+		if h.Point != p {
+			continue
+		}
+
+		err := h.run(v, d, m.c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runErrorHooks runs HookOnError hooks, their errors are printed.
+func (m *Migrate) runErrorHooks(v *Version, d Direction, err error) {
+	if m.hooks.OnError != nil {
+		if hookErr := m.hooks.OnError(v, d, m.c, err); hookErr != nil {
+			fmt.Fprintf(m.output(), "Warning: on_error hook failed: %v\n", hookErr)
+		}
+	}
+
+	for _, h := range m.sqlHooks {
+		if h.Point != HookOnError {
+			continue
+		}
+
+		if hookErr := h.run(v, d, m.c); hookErr != nil {
+			fmt.Fprintf(m.output(), "Warning: %v\n", hookErr)
+		}
+	}
+}
+
+// runAll runs migrations between HookBeforeAll and HookAfterAll hooks.
+func (m *Migrate) runAll(d Direction, migrations []Migration, run func(Migration) error) error {
+	if len(migrations) == 0 {
+		return nil
+	}
+
+	err := m.runHooks(HookBeforeAll, nil, d)
+	if err != nil {
+		m.runErrorHooks(nil, d, err)
+		return err
+	}
+
+	for _, migration := range migrations {
+		err = run(migration)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = m.runHooks(HookAfterAll, nil, d)
+	if err != nil {
+		m.runErrorHooks(nil, d, err)
+		return err
+	}
+
+	return nil
+}
+
+// runEach runs migration between HookBeforeEach and HookAfterEach hooks.
+func (m *Migrate) runEach(d Direction, migration Migration, run func() error) error {
+	v := migration.Version()
+
+	err := m.runHooks(HookBeforeEach, &v, d)
+	if err == nil {
+		err = run()
+	}
+	if err == nil {
+		err = m.runHooks(HookAfterEach, &v, d)
+	}
+
+	if err != nil {
+		m.runErrorHooks(&v, d, err)
+	}
+
+	return err
+}
@@ -0,0 +1,103 @@
+package migo
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(path.Join(dir, "_after_each.sql"), []byte("NOTIFY ${migo_direction} ${migo_version};"), 0644)
+	ioutil.WriteFile(path.Join(dir, "_after_all.sql"), []byte("REFRESH MATERIALIZED VIEW stats;"), 0644)
+
+	c := &HistoryConnectionMock{}
+	m := newDependentMigrate(c, map[string][]string{
+		"1-users":  nil,
+		"2-emails": nil,
+	})
+	m.loaders = append(m.loaders, NewSQLMigrationLoader(dir))
+
+	hook := func(name string) Hook {
+		return func(v *Version, d Direction, c Connection) error {
+			return c.Exec(fmt.Sprintf("%s %s %v", name, d, v))
+		}
+	}
+	m.SetHooks(Hooks{
+		BeforeAll:  hook("before all"),
+		BeforeEach: hook("before each"),
+	})
+
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"before all up <nil>",
+		"before each up 1-users",
+		"UP 1-users",
+		"NOTIFY up 1-users;",
+		"before each up 2-emails",
+		"UP 2-emails",
+		"NOTIFY up 2-emails;",
+		"REFRESH MATERIALIZED VIEW stats;",
+	}
+	if strings.Join(c.sqls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected statements %q", c.sqls)
+	}
+
+	c.sqls = nil
+	err = m.UpToLatest()
+	if err != nil || len(c.sqls) != 0 {
+		t.Errorf("hooks should not run without migrations: %v %q", err, c.sqls)
+	}
+}
+
+func TestHooksOnError(t *testing.T) {
+	c := &HistoryConnectionMock{}
+	m := newDependentMigrate(c, map[string][]string{
+		"1-users":  nil,
+		"2-emails": nil,
+	})
+
+	failure := errors.New("cache is not available")
+	var failed *Version
+	m.SetHooks(Hooks{
+		AfterEach: func(v *Version, d Direction, c Connection) error {
+			if v.Name == "users" {
+				return failure
+			}
+			return nil
+		},
+		AfterAll: func(v *Version, d Direction, c Connection) error {
+			t.Error("AfterAll should not run after failure")
+			return nil
+		},
+		OnError: func(v *Version, d Direction, c Connection, err error) error {
+			failed = v
+			if !errors.Is(err, failure) {
+				t.Errorf("unexpected error %v", err)
+			}
+			return nil
+		},
+	})
+
+	err := m.UpToLatest()
+	if !errors.Is(err, failure) {
+		t.Errorf("expected hook error, got %v", err)
+	}
+
+	if failed == nil || failed.String() != "1-users" || len(c.sqls) != 1 {
+		t.Errorf("unexpected failed version %v, statements %q", failed, c.sqls)
+	}
+}
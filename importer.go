@@ -0,0 +1,358 @@
+package migo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrQueryNotSupported = errors.New("connection doesn't support queries")
+
+// ImportSource is a migration tool which state can be imported.
+type ImportSource string
+
+const (
+	// ImportGolangMigrate reads `schema_migrations` table of golang-migrate.
+	ImportGolangMigrate ImportSource = "golang-migrate"
+	// ImportGoose reads `goose_db_version` table of goose.
+	ImportGoose ImportSource = "goose"
+	// ImportFlyway reads `flyway_schema_history` table of Flyway.
+	ImportFlyway ImportSource = "flyway"
+)
+
+var importTables = map[ImportSource]string{
+	ImportGolangMigrate: "schema_migrations",
+	ImportGoose:         "goose_db_version",
+	ImportFlyway:        "flyway_schema_history",
+}
+
+// VersionMapping maps version of other tool to migo version
+// without name, e.g. `0003` to `3`.
+type VersionMapping func(foreign string) (string, error)
+
+// NumericVersionMapping maps numeric versions (`0003`, Flyway `1.2.3`)
+// to semver, leading zeros are removed.
+func NumericVersionMapping(foreign string) (string, error) {
+	segs := strings.Split(foreign, ".")
+	if len(segs) > 4 {
+		return "", fmt.Errorf("version '%s' has too many segments", foreign)
+	}
+
+	for i, seg := range segs {
+		n, err := strconv.ParseUint(seg, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("version '%s' is not numeric", foreign)
+		}
+		segs[i] = strconv.FormatUint(n, 10)
+	}
+
+	if len(segs) == 4 {
+		return strings.Join(segs, "."), nil
+	}
+
+	for len(segs) < 3 {
+		segs = append(segs, "0")
+	}
+
+	return strings.Join(segs, "."), nil
+}
+
+// TimestampVersionMapping maps timestamp versions (`20200102150405` or
+// unix seconds) to `1.0.0.<unix seconds>`, the format of the default version template.
+func TimestampVersionMapping(foreign string) (string, error) {
+	if len(foreign) == len("20060102150405") {
+		t, err := time.Parse("20060102150405", foreign)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("1.0.0.%d", t.Unix()), nil
+	}
+
+	unix, err := strconv.ParseInt(foreign, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("version '%s' is not a timestamp", foreign)
+	}
+
+	return fmt.Sprintf("1.0.0.%d", unix), nil
+}
+
+// ForeignVersion is a version applied by other tool.
+type ForeignVersion struct {
+	Version     string
+	Description string
+	// Baseline is true when all versions up to this one are applied,
+	// e.g. golang-migrate keeps only the latest version.
+	Baseline bool
+}
+
+// ImportOptions configures import of the state of other migration tool.
+type ImportOptions struct {
+	Source ImportSource
+	// Table overrides default table name of the source.
+	Table string
+	// Mapping maps versions of the source, NumericVersionMapping by default.
+	Mapping VersionMapping
+	// DryRun only builds the plan, nothing is written.
+	DryRun bool
+}
+
+// ImportPlan describes what import does.
+type ImportPlan struct {
+	// ToApply are versions that are marked as applied by import.
+	ToApply []string
+	// AlreadyApplied are versions that are already marked as applied in migo.
+	AlreadyApplied []string
+	// Unmatched are versions of the source without loaded migo migration.
+	Unmatched []ForeignVersion
+}
+
+// String returns plan as a diff: `+` for versions to apply,
+// `=` for already applied and `?` for unmatched versions.
+func (p *ImportPlan) String() string {
+	b := &strings.Builder{}
+	for _, v := range p.ToApply {
+		fmt.Fprintf(b, "+ %s\n", v)
+	}
+
+	for _, v := range p.AlreadyApplied {
+		fmt.Fprintf(b, "= %s\n", v)
+	}
+
+	for _, v := range p.Unmatched {
+		fmt.Fprintf(b, "? %s %s\n", v.Version, v.Description)
+	}
+
+	return b.String()
+}
+
+// Import reads applied versions from the table of other migration tool,
+// maps them onto loaded migrations and marks matched migrations as applied.
+// Connection must implement Querier.
+func (m *Migrate) Import(opts ImportOptions) (*ImportPlan, error) {
+	q, ok := m.c.(Querier)
+	if !ok {
+		return nil, ErrQueryNotSupported
+	}
+
+	if opts.Mapping == nil {
+		opts.Mapping = NumericVersionMapping
+	}
+
+	table := opts.Table
+	if table == "" {
+		table = importTables[opts.Source]
+	}
+
+	err := m.loadMigrations()
+	if err != nil {
+		return nil, errors.New("can't load migrations " + err.Error())
+	}
+
+	byVersion := map[string]Migration{}
+	for _, mig := range m.migrations {
+		byVersion[mig.Version().StringWithoutName()] = mig
+	}
+
+	var foreign []ForeignVersion
+	switch opts.Source {
+	case ImportGolangMigrate:
+		foreign, err = golangMigrateVersions(q, table)
+	case ImportGoose:
+		foreign, err = gooseVersions(q, table)
+	case ImportFlyway:
+		foreign, err = flywayVersions(q, table)
+	default:
+		return nil, fmt.Errorf("unknown import source '%s'", opts.Source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	verStrs, err := m.c.LoadVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[string]bool{}
+	for _, v := range verStrs {
+		applied[v] = true
+	}
+
+	plan := &ImportPlan{}
+	planned := map[string]bool{}
+	addToPlan := func(mig Migration) {
+		v := mig.Version().String()
+		if planned[v] {
+			return
+		}
+		planned[v] = true
+
+		if applied[v] {
+			plan.AlreadyApplied = append(plan.AlreadyApplied, v)
+		} else {
+			plan.ToApply = append(plan.ToApply, v)
+		}
+	}
+
+	sorted := m.sort(m.migrations, true)
+	for _, fv := range foreign {
+		mapped, err := opts.Mapping(fv.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		mappedVer, err := VersionFromString(mapped + "-")
+		if err != nil {
+			return nil, err
+		}
+		mapped = mappedVer.StringWithoutName()
+
+		if !fv.Baseline {
+			mig, found := byVersion[mapped]
+			if !found {
+				plan.Unmatched = append(plan.Unmatched, fv)
+				continue
+			}
+
+			addToPlan(mig)
+			continue
+		}
+
+		if _, found := byVersion[mapped]; !found {
+			plan.Unmatched = append(plan.Unmatched, fv)
+		}
+
+		for _, mig := range sorted {
+			v := mig.Version()
+			if !mappedVer.GreaterThanOrEqual(&v) {
+				break
+			}
+
+			addToPlan(mig)
+		}
+	}
+
+	if opts.DryRun {
+		return plan, nil
+	}
+
+	for _, v := range plan.ToApply {
+		err := m.Force(v)
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	return plan, nil
+}
+
+// golangMigrateVersions returns the current version of golang-migrate
+// as a baseline, because it keeps only the latest version.
+func golangMigrateVersions(q Querier, table string) ([]ForeignVersion, error) {
+	rows, err := q.Query("SELECT version, dirty FROM " + table)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	if asBool(rows[0]["dirty"]) {
+		return nil, fmt.Errorf("golang-migrate version %v is dirty, fix it before import", rows[0]["version"])
+	}
+
+	return []ForeignVersion{{
+		Version:  fmt.Sprint(rows[0]["version"]),
+		Baseline: true,
+	}}, nil
+}
+
+func gooseVersions(q Querier, table string) ([]ForeignVersion, error) {
+	rows, err := q.Query("SELECT version_id, is_applied FROM " + table + " ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+
+	// the latest row of a version defines if it is applied
+	applied := map[string]bool{}
+	order := []string{}
+	for _, row := range rows {
+		v := fmt.Sprint(row["version_id"])
+		if v == "0" {
+			continue
+		}
+
+		if _, seen := applied[v]; !seen {
+			order = append(order, v)
+		}
+		applied[v] = asBool(row["is_applied"])
+	}
+
+	result := []ForeignVersion{}
+	for _, v := range order {
+		if applied[v] {
+			result = append(result, ForeignVersion{Version: v})
+		}
+	}
+
+	return result, nil
+}
+
+func flywayVersions(q Querier, table string) ([]ForeignVersion, error) {
+	rows, err := q.Query("SELECT version, description, type, success FROM " + table + " ORDER BY installed_rank")
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[string]*ForeignVersion{}
+	order := []string{}
+	for _, row := range rows {
+		if row["version"] == nil || !asBool(row["success"]) {
+			continue
+		}
+
+		v := fmt.Sprint(row["version"])
+		typ := strings.ToUpper(fmt.Sprint(row["type"]))
+		if strings.HasPrefix(typ, "UNDO") || typ == "DELETE" {
+			applied[v] = nil
+			continue
+		}
+
+		if _, seen := applied[v]; !seen {
+			order = append(order, v)
+		}
+
+		applied[v] = &ForeignVersion{
+			Version:     v,
+			Description: fmt.Sprint(row["description"]),
+			Baseline:    typ == "BASELINE",
+		}
+	}
+
+	result := []ForeignVersion{}
+	for _, v := range order {
+		if applied[v] != nil {
+			result = append(result, *applied[v])
+		}
+	}
+
+	return result, nil
+}
+
+func asBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case int64:
+		return b != 0
+	case string:
+		parsed, _ := strconv.ParseBool(b)
+		return parsed
+	}
+
+	return false
+}
@@ -0,0 +1,137 @@
+package migo
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+type QuerierConnectionMock struct {
+	HistoryConnectionMock
+	rows map[string][]map[string]interface{}
+}
+
+func (c *QuerierConnectionMock) Query(sql string, values ...interface{}) ([]map[string]interface{}, error) {
+	for table, rows := range c.rows {
+		if strings.Contains(sql, " FROM "+table) {
+			return rows, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func newImportMigrate(c Connection, versions ...string) *Migrate {
+	m := NewMigrate(c)
+	m.SetOutput(ioutil.Discard)
+	for _, ver := range versions {
+		mig := &SQLMigration{}
+		v, _ := VersionFromString(ver)
+		mig.SetVersion(v)
+		m.Add(mig)
+	}
+
+	return m
+}
+
+func TestImportGolangMigrate(t *testing.T) {
+	c := &QuerierConnectionMock{rows: map[string][]map[string]interface{}{
+		"schema_migrations": {{"version": int64(2), "dirty": false}},
+	}}
+	c.versions = []string{"1-create-users"}
+
+	m := newImportMigrate(c, "1-create-users", "2-add-email", "3-add-phone")
+	plan, err := m.Import(ImportOptions{Source: ImportGolangMigrate, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.String() != "+ 2-add-email\n= 1-create-users\n" {
+		t.Errorf("unexpected plan:\n%s", plan)
+	}
+
+	if len(c.versions) != 1 {
+		t.Error("dry run should not change versions")
+	}
+
+	_, err = m.Import(ImportOptions{Source: ImportGolangMigrate})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(c.versions, ",") != "1-create-users,2-add-email" {
+		t.Error("unexpected versions", c.versions)
+	}
+}
+
+func TestImportGolangMigrateDirty(t *testing.T) {
+	c := &QuerierConnectionMock{rows: map[string][]map[string]interface{}{
+		"schema_migrations": {{"version": int64(2), "dirty": true}},
+	}}
+
+	_, err := newImportMigrate(c, "1-create-users").Import(ImportOptions{Source: ImportGolangMigrate})
+	if err == nil {
+		t.Error("dirty version should not be imported")
+	}
+}
+
+func TestImportFlyway(t *testing.T) {
+	c := &QuerierConnectionMock{rows: map[string][]map[string]interface{}{
+		"flyway_schema_history": {
+			{"version": "1", "description": "<< Flyway Baseline >>", "type": "BASELINE", "success": true},
+			{"version": "1.1", "description": "add email", "type": "SQL", "success": true},
+			{"version": nil, "description": "views", "type": "SQL", "success": true},
+			{"version": "1.2", "description": "broken", "type": "SQL", "success": false},
+			{"version": "1.3", "description": "unknown", "type": "SQL", "success": true},
+		},
+	}}
+
+	m := newImportMigrate(c, "0.9.0-initial", "1-create-users", "1.1.0-add-email", "1.2.0-broken")
+	plan, err := m.Import(ImportOptions{Source: ImportFlyway, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(plan.ToApply, ",") != "0.9.0-initial,1-create-users,1.1.0-add-email" {
+		t.Error("unexpected versions to apply", plan.ToApply)
+	}
+
+	if len(plan.Unmatched) != 1 || plan.Unmatched[0].Version != "1.3" {
+		t.Error("unexpected unmatched versions", plan.Unmatched)
+	}
+}
+
+func TestImportGooseTimestamps(t *testing.T) {
+	c := &QuerierConnectionMock{rows: map[string][]map[string]interface{}{
+		"goose_db_version": {
+			{"version_id": int64(0), "is_applied": true},
+			{"version_id": int64(20200102150405), "is_applied": true},
+			{"version_id": int64(20200103150405), "is_applied": true},
+			{"version_id": int64(20200103150405), "is_applied": false},
+		},
+	}}
+
+	m := newImportMigrate(c, "1.0.0.1577977445-create-users", "1.0.0.1578063845-add-email")
+	plan, err := m.Import(ImportOptions{Source: ImportGoose, Mapping: TimestampVersionMapping, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(plan.ToApply, ",") != "1.0.0.1577977445-create-users" {
+		t.Error("unexpected versions to apply", plan.ToApply)
+	}
+}
+
+func TestNumericVersionMapping(t *testing.T) {
+	for foreign, expected := range map[string]string{
+		"0003":    "3.0.0",
+		"1.2":     "1.2.0",
+		"1.02.3":  "1.2.3",
+		"1.2.3.4": "1.2.3.4",
+	} {
+		v, err := NumericVersionMapping(foreign)
+		if err != nil || v != expected {
+			t.Error("expected", expected, "have", v, err)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+// Package osuser is shared by migo and its connections,
+// it isn't part of the public API.
+package osuser
+
+import (
+	"os"
+	"os/user"
+)
+
+// Name returns name of the OS user that runs migrations.
+func Name() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
@@ -0,0 +1,372 @@
+package migo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/walkline/migo/sqlscanner"
+)
+
+const directiveAllow = "allow"
+
+// LintRule is a check of sql migrations made by Lint.
+type LintRule string
+
+const (
+	// LintDropTable reports `DROP TABLE` in up migration.
+	LintDropTable LintRule = "drop-table"
+	// LintDropColumn reports `ALTER TABLE ... DROP COLUMN` in up migration.
+	LintDropColumn LintRule = "drop-column"
+	// LintNotNullWithoutDefault reports `ALTER TABLE ... ADD COLUMN ... NOT NULL` without default,
+	// it fails on tables with rows.
+	LintNotNullWithoutDefault LintRule = "not-null-without-default"
+	// LintIndexNotConcurrent reports postgres `CREATE INDEX` without `CONCURRENTLY`
+	// on existing tables, it blocks writes while index is built.
+	LintIndexNotConcurrent LintRule = "index-not-concurrent"
+	// LintUpdateWithoutWhere reports `UPDATE` without `WHERE`.
+	LintUpdateWithoutWhere LintRule = "update-without-where"
+	// LintDeleteWithoutWhere reports `DELETE` without `WHERE`.
+	LintDeleteWithoutWhere LintRule = "delete-without-where"
+	// LintRename reports renames of tables and columns, they break code
+	// that is running while migration is applied.
+	LintRename LintRule = "rename"
+	// LintUnrevertedTable reports table created in up migration
+	// that isn't dropped in down migration.
+	LintUnrevertedTable LintRule = "unreverted-table"
+)
+
+// LintSeverity is a severity of issues of a rule.
+type LintSeverity string
+
+const (
+	LintError   LintSeverity = "error"
+	LintWarning LintSeverity = "warning"
+	// LintOff disables rule.
+	LintOff LintSeverity = "off"
+)
+
+// DefaultLintRules are rules of Lint with their default severities.
+var DefaultLintRules = map[LintRule]LintSeverity{
+	LintDropTable:             LintError,
+	LintDropColumn:            LintError,
+	LintNotNullWithoutDefault: LintError,
+	LintIndexNotConcurrent:    LintWarning,
+	LintUpdateWithoutWhere:    LintWarning,
+	LintDeleteWithoutWhere:    LintWarning,
+	LintRename:                LintWarning,
+	LintUnrevertedTable:       LintError,
+}
+
+// LintOptions configures Lint.
+type LintOptions struct {
+	// Rules overrides severities of DefaultLintRules, LintOff disables rule.
+	Rules map[LintRule]LintSeverity
+	// Dialect is used to split migrations into statements, by default
+	// dialect of migration or connection is used. Postgres only rules
+	// are checked for DialectPostgres.
+	Dialect sqlscanner.Dialect
+}
+
+func (o LintOptions) severity(r LintRule) LintSeverity {
+	if s, found := o.Rules[r]; found {
+		return s
+	}
+
+	return DefaultLintRules[r]
+}
+
+// LintIssue is a risky statement found by Lint.
+type LintIssue struct {
+	File     string
+	Line     int
+	Version  string
+	Rule     LintRule
+	Severity LintSeverity
+	Message  string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s:%d: %s %s: %s", i.File, i.Line, i.Severity, i.Rule, i.Message)
+}
+
+// LintReport lists issues of migrations in the order of versions.
+type LintReport struct {
+	Issues []LintIssue
+}
+
+// HasErrors returns true when report has issues with LintError severity.
+func (r *LintReport) HasErrors() bool {
+	for _, i := range r.Issues {
+		if i.Severity == LintError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String returns an issue per line.
+func (r *LintReport) String() string {
+	b := &strings.Builder{}
+	for _, i := range r.Issues {
+		fmt.Fprintln(b, i)
+	}
+
+	return b.String()
+}
+
+var (
+	lintStringRe       = regexp.MustCompile(`'(?:[^']|'')*'`)
+	lintCommentRe      = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	lintCreateTableRe  = regexp.MustCompile(`^CREATE (?:TEMPORARY |TEMP )?TABLE (?:IF NOT EXISTS )?([^\s(]+)`)
+	lintDropTableRe    = regexp.MustCompile(`^DROP TABLE (?:IF EXISTS )?(.+?)(?: CASCADE| RESTRICT)?;?$`)
+	lintDropColumnRe   = regexp.MustCompile(`^ALTER TABLE .*\bDROP COLUMN\b`)
+	lintAddColumnRe    = regexp.MustCompile(`^ALTER TABLE .*\bADD\b.*\bNOT NULL\b`)
+	lintCreateIndexRe  = regexp.MustCompile(`^CREATE (?:UNIQUE )?INDEX (CONCURRENTLY )?.*?\bON (?:ONLY )?([^\s(]+)`)
+	lintUpdateRe       = regexp.MustCompile(`^UPDATE `)
+	lintDeleteRe       = regexp.MustCompile(`^DELETE `)
+	lintWhereRe        = regexp.MustCompile(`\bWHERE\b`)
+	lintDefaultRe      = regexp.MustCompile(`\bDEFAULT\b`)
+	lintRenameRe       = regexp.MustCompile(`^(?:ALTER TABLE .*\bRENAME\b|RENAME TABLE |EXEC(?:UTE)? SP_RENAME\b|SP_RENAME\b)`)
+	lintSpacesRe       = regexp.MustCompile(`\s+`)
+	lintIdentifierTrim = "`\"[]"
+)
+
+// lintStatement is a statement of migration prepared for checks.
+type lintStatement struct {
+	line int
+	// norm is upper cased statement without comments, string literals and extra spaces.
+	norm string
+}
+
+func normalizeStatement(s string) string {
+	s = lintStringRe.ReplaceAllString(s, "''")
+	s = lintCommentRe.ReplaceAllString(s, " ")
+	s = lintSpacesRe.ReplaceAllString(s, " ")
+
+	return strings.ToUpper(strings.TrimSpace(s))
+}
+
+func tableName(s string) string {
+	parts := strings.Split(s, ".")
+	return strings.ToLower(strings.Trim(parts[len(parts)-1], lintIdentifierTrim))
+}
+
+// Lint checks sql migrations for risky statements,
+// go migrations are skipped. Issues of a migration are suppressed
+// with `-- migo:allow <rule> ...` directive in its file.
+func (m *Migrate) Lint(opts LintOptions) (*LintReport, error) {
+	err := m.loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("can't load migrations: %w", err)
+	}
+
+	dialect := opts.Dialect
+	if c, ok := m.c.(DialectConnection); ok && dialect == sqlscanner.DialectDefault {
+		dialect = c.Dialect()
+	}
+
+	report := &LintReport{}
+	for _, migration := range m.sort(m.migrations, true) {
+		sqlMigration, ok := unwrapMigration(migration).(*SQLMigration)
+		if !ok {
+			continue
+		}
+
+		issues, err := lintSQLMigration(sqlMigration, dialect, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	return report, nil
+}
+
+func lintSQLMigration(m *SQLMigration, dialect sqlscanner.Dialect, opts LintOptions) ([]LintIssue, error) {
+	if m.Dialect != sqlscanner.DialectDefault {
+		dialect = m.Dialect
+	}
+
+	upFile, downFile := m.UpPath, m.DownPath
+	var up, down []lintStatement
+	hasDown := false
+
+	if m.isSingleFile() {
+		upFile, downFile = m.Path, m.Path
+
+		data, err := ioutil.ReadFile(m.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := parseSQLSections(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Path, err)
+		}
+
+		up, err = lintStatements(data, s.up, dialect)
+		if err != nil {
+			return nil, err
+		}
+
+		down, err = lintStatements(data, s.down, dialect)
+		if err != nil {
+			return nil, err
+		}
+		hasDown = s.hasDown
+	} else {
+		if upFile == "" {
+			return nil, nil
+		}
+
+		data, err := ioutil.ReadFile(upFile)
+		if err != nil {
+			return nil, err
+		}
+
+		up, err = lintStatements(data, data, dialect)
+		if err != nil {
+			return nil, err
+		}
+
+		if downFile != "" {
+			data, err := ioutil.ReadFile(downFile)
+			if err != nil {
+				return nil, err
+			}
+
+			down, err = lintStatements(data, data, dialect)
+			if err != nil {
+				return nil, err
+			}
+			hasDown = true
+		}
+	}
+
+	allowed := map[LintRule]bool{}
+	for _, d := range m.directives() {
+		if d.name == directiveAllow {
+			for _, arg := range d.args {
+				allowed[LintRule(strings.ToLower(arg))] = true
+			}
+		}
+	}
+
+	issues := []LintIssue{}
+	report := func(file string, line int, rule LintRule, format string, args ...interface{}) {
+		severity := opts.severity(rule)
+		if severity == LintOff || allowed[rule] {
+			return
+		}
+
+		issues = append(issues, LintIssue{
+			File:     file,
+			Line:     line,
+			Version:  m.Version().String(),
+			Rule:     rule,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	created := map[string]int{}
+	createdOrder := []string{}
+	for _, s := range up {
+		if match := lintCreateTableRe.FindStringSubmatch(s.norm); match != nil {
+			name := tableName(match[1])
+			if _, found := created[name]; !found {
+				created[name] = s.line
+				createdOrder = append(createdOrder, name)
+			}
+		}
+
+		if lintDropTableRe.MatchString(s.norm) {
+			report(upFile, s.line, LintDropTable, "table is dropped, data is lost")
+		}
+
+		if lintDropColumnRe.MatchString(s.norm) {
+			report(upFile, s.line, LintDropColumn, "column is dropped, data is lost and running code may still use it")
+		}
+
+		if lintAddColumnRe.MatchString(s.norm) && !lintDefaultRe.MatchString(s.norm) {
+			report(upFile, s.line, LintNotNullWithoutDefault, "NOT NULL column without DEFAULT fails on table with rows")
+		}
+
+		if match := lintCreateIndexRe.FindStringSubmatch(s.norm); match != nil && dialect == sqlscanner.DialectPostgres && match[1] == "" {
+			if _, found := created[tableName(match[2])]; !found {
+				report(upFile, s.line, LintIndexNotConcurrent, "index is built with lock on writes, consider CREATE INDEX CONCURRENTLY")
+			}
+		}
+
+		if lintUpdateRe.MatchString(s.norm) && !lintWhereRe.MatchString(s.norm) {
+			report(upFile, s.line, LintUpdateWithoutWhere, "UPDATE without WHERE changes every row")
+		}
+
+		if lintDeleteRe.MatchString(s.norm) && !lintWhereRe.MatchString(s.norm) {
+			report(upFile, s.line, LintDeleteWithoutWhere, "DELETE without WHERE removes every row")
+		}
+
+		if lintRenameRe.MatchString(s.norm) {
+			report(upFile, s.line, LintRename, "rename breaks code that uses the old name, add new object and remove old one in a later migration")
+		}
+	}
+
+	if !hasDown || m.Irreversible() {
+		return issues, nil
+	}
+
+	dropped := map[string]bool{}
+	for _, s := range down {
+		if match := lintDropTableRe.FindStringSubmatch(s.norm); match != nil {
+			for _, name := range strings.Split(match[1], ",") {
+				dropped[tableName(strings.TrimSpace(name))] = true
+			}
+		}
+	}
+
+	for _, name := range createdOrder {
+		if !dropped[name] {
+			report(upFile, created[name], LintUnrevertedTable, "table '%s' is not dropped in %s", name, downFile)
+		}
+	}
+
+	return issues, nil
+}
+
+// lintStatements splits part of the file into statements,
+// lines are counted from the beginning of the file.
+func lintStatements(file, part []byte, dialect sqlscanner.Dialect) ([]lintStatement, error) {
+	statements := []lintStatement{}
+	offset := bytes.Index(file, part)
+	if offset < 0 {
+		offset = 0
+	}
+
+	scanner := sqlscanner.NewDialectScanner(bytes.NewReader(part), dialect)
+	for scanner.Scan() {
+		text := scanner.Bytes()
+
+		line := 0
+		if i := bytes.Index(file[offset:], text); i >= 0 {
+			offset += i
+			line = bytes.Count(file[:offset], []byte("\n")) + 1
+			offset += len(text)
+		}
+
+		statements = append(statements, lintStatement{
+			line: line,
+			norm: normalizeStatement(string(text)),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return statements, nil
+}
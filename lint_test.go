@@ -0,0 +1,75 @@
+package migo
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/walkline/migo/sqlscanner"
+)
+
+func TestLint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"1-create.up.sql":   "CREATE TABLE users (id INT);\nCREATE TABLE orders (id INT);\nCREATE INDEX users_id ON users (id);\n",
+		"1-create.down.sql": "DROP TABLE users;\n",
+		"2-change.sql": "-- migo:up\n" +
+			"ALTER TABLE users ADD COLUMN email TEXT NOT NULL;\n" +
+			"ALTER TABLE users ADD COLUMN phone TEXT NOT NULL DEFAULT '';\n" +
+			"CREATE INDEX users_email ON users (email);\n" +
+			"UPDATE users SET email = 'WHERE';\n" +
+			"DELETE FROM orders WHERE id = 1;\n" +
+			"ALTER TABLE orders RENAME TO purchases;\n" +
+			"-- migo:down\n" +
+			"SELECT 1;\n",
+		"3-drop.sql": "-- migo:allow drop-table\n-- migo:up\nDROP TABLE purchases;\nALTER TABLE users DROP COLUMN phone;\n-- migo:down\nSELECT 1;\n",
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewMigrate(nil, NewSQLMigrationLoader(dir))
+	report, err := m.Lint(LintOptions{
+		Dialect: sqlscanner.DialectPostgres,
+		Rules:   map[LintRule]LintSeverity{LintRename: LintOff},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		file string
+		line int
+		rule LintRule
+	}{
+		{"1-create.up.sql", 2, LintUnrevertedTable},
+		{"2-change.sql", 2, LintNotNullWithoutDefault},
+		{"2-change.sql", 4, LintIndexNotConcurrent},
+		{"2-change.sql", 5, LintUpdateWithoutWhere},
+		{"3-drop.sql", 4, LintDropColumn},
+	}
+
+	if len(report.Issues) != len(expected) {
+		t.Fatalf("unexpected issues\n%s", report)
+	}
+
+	for i, e := range expected {
+		issue := report.Issues[i]
+		if issue.File != path.Join(dir, e.file) || issue.Line != e.line || issue.Rule != e.rule {
+			t.Errorf("expected %s:%d %s, got %s", e.file, e.line, e.rule, issue)
+		}
+	}
+
+	if !report.HasErrors() {
+		t.Error("report should have errors")
+	}
+}
@@ -0,0 +1,265 @@
+package migotest
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+
+	"github.com/walkline/migo"
+)
+
+// ErrInjected is returned by RecordingConnection for injected failures
+// without own error.
+var ErrInjected = errors.New("injected failure")
+
+// EventKind is a kind of event recorded by RecordingConnection.
+type EventKind string
+
+const (
+	EventExec     EventKind = "exec"
+	EventBegin    EventKind = "begin"
+	EventCommit   EventKind = "commit"
+	EventRollback EventKind = "rollback"
+)
+
+// Event is a statement or a transaction boundary.
+type Event struct {
+	Kind   EventKind
+	SQL    string
+	Values []interface{}
+	// Tx is the number of transaction starting from 1,
+	// it is 0 for statements executed outside of transaction.
+	Tx int
+	// Err is the error returned for the event.
+	Err error
+}
+
+type failure struct {
+	n       int
+	pattern *regexp.Regexp
+	err     error
+}
+
+// RecordingConnection is an in-memory migo.Connection for unit tests.
+// It records executed statements with bound values and transaction
+// boundaries, stores versions in memory and fails statements on demand.
+// It is safe for concurrent use.
+type RecordingConnection struct {
+	mu       sync.Mutex
+	events   []Event
+	versions []string
+	failures []failure
+	execs    int
+	txs      int
+}
+
+// NewRecordingConnection creates connection without applied versions.
+func NewRecordingConnection() *RecordingConnection {
+	return &RecordingConnection{}
+}
+
+// FailOnStatement makes the n-th statement (starting from 1, counted
+// inside and outside of transactions) fail with `err`, ErrInjected when it is nil.
+func (c *RecordingConnection) FailOnStatement(n int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = append(c.failures, failure{n: n, err: err})
+}
+
+// FailOnMatch makes statements matching regular expression `pattern`
+// fail with `err`, ErrInjected when it is nil.
+func (c *RecordingConnection) FailOnMatch(pattern string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = append(c.failures, failure{pattern: regexp.MustCompile(pattern), err: err})
+}
+
+func (c *RecordingConnection) Exec(sql string, values ...interface{}) error {
+	return c.exec(nil, sql, values)
+}
+
+// exec records statement of the transaction, `tx` is nil outside of transactions.
+func (c *RecordingConnection) exec(tx *RecordingTransaction, sql string, values []interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	if tx != nil {
+		if tx.done {
+			return errors.New("transaction is finished")
+		}
+		n = tx.n
+	}
+
+	c.execs++
+
+	var err error
+	for _, f := range c.failures {
+		if (f.pattern == nil && f.n == c.execs) || (f.pattern != nil && f.pattern.MatchString(sql)) {
+			err = f.err
+			if err == nil {
+				err = ErrInjected
+			}
+			break
+		}
+	}
+
+	c.events = append(c.events, Event{
+		Kind:   EventExec,
+		SQL:    sql,
+		Values: values,
+		Tx:     n,
+		Err:    err,
+	})
+
+	return err
+}
+
+// LoadVersions returns applied versions, `0-null` when there are none.
+func (c *RecordingConnection) LoadVersions() ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.versions) == 0 {
+		return []string{"0-null"}, nil
+	}
+
+	return append([]string{}, c.versions...), nil
+}
+
+func (c *RecordingConnection) SetVersion(v string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.versions = append(c.versions, v)
+
+	return nil
+}
+
+// RemoveVersion removes version from applied ones.
+func (c *RecordingConnection) RemoveVersion(v string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, ver := range c.versions {
+		if ver == v {
+			c.versions = append(c.versions[:i], c.versions[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.New("version '" + v + "' is not applied")
+}
+
+// Versions returns applied versions in the order they were set.
+func (c *RecordingConnection) Versions() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string{}, c.versions...)
+}
+
+func (c *RecordingConnection) Tx() (migo.Transaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.txs++
+	c.events = append(c.events, Event{Kind: EventBegin, Tx: c.txs})
+
+	return &RecordingTransaction{c: c, n: c.txs}, nil
+}
+
+// Events returns all recorded events.
+func (c *RecordingConnection) Events() []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Event{}, c.events...)
+}
+
+// Statements returns SQL of all executed statements, including failed ones.
+func (c *RecordingConnection) Statements() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sqls := []string{}
+	for _, e := range c.events {
+		if e.Kind == EventExec {
+			sqls = append(sqls, e.SQL)
+		}
+	}
+
+	return sqls
+}
+
+// Committed returns SQL of successful statements that are executed outside
+// of transaction or in committed transactions.
+func (c *RecordingConnection) Committed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	committed := map[int]bool{0: true}
+	for _, e := range c.events {
+		if e.Kind == EventCommit && e.Err == nil {
+			committed[e.Tx] = true
+		}
+	}
+
+	sqls := []string{}
+	for _, e := range c.events {
+		if e.Kind == EventExec && e.Err == nil && committed[e.Tx] {
+			sqls = append(sqls, e.SQL)
+		}
+	}
+
+	return sqls
+}
+
+// Reset removes recorded events, versions and failures.
+func (c *RecordingConnection) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = nil
+	c.versions = nil
+	c.failures = nil
+	c.execs = 0
+	c.txs = 0
+}
+
+// RecordingTransaction is a transaction of RecordingConnection,
+// its state is guarded by the mutex of the connection.
+type RecordingTransaction struct {
+	c    *RecordingConnection
+	n    int
+	done bool
+}
+
+func (tx *RecordingTransaction) Exec(sql string, values ...interface{}) error {
+	return tx.c.exec(tx, sql, values)
+}
+
+func (tx *RecordingTransaction) Commit() error {
+	return tx.finish(EventCommit)
+}
+
+func (tx *RecordingTransaction) Rollback() error {
+	return tx.finish(EventRollback)
+}
+
+func (tx *RecordingTransaction) finish(kind EventKind) error {
+	tx.c.mu.Lock()
+	defer tx.c.mu.Unlock()
+
+	var err error
+	if tx.done {
+		err = errors.New("transaction is finished")
+	}
+	tx.done = true
+
+	tx.c.events = append(tx.c.events, Event{Kind: kind, Tx: tx.n, Err: err})
+
+	return err
+}
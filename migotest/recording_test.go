@@ -0,0 +1,119 @@
+package migotest
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/walkline/migo"
+)
+
+func TestRecordingConnectionRollback(t *testing.T) {
+	f, err := ioutil.TempFile("", "1-users-*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	f.WriteString("-- migo:up\nCREATE TABLE users (id INT);\nINSERT INTO users VALUES (1);\n-- migo:down\nDROP TABLE users;\n")
+	f.Close()
+
+	c := NewRecordingConnection()
+	c.FailOnMatch("^INSERT", nil)
+
+	mig := &migo.SQLMigration{Path: f.Name()}
+	v, _ := migo.VersionFromString("1-users")
+	mig.SetVersion(v)
+
+	m := migo.NewMigrate(c)
+	m.SetOutput(ioutil.Discard)
+	m.Add(mig)
+
+	err = m.UpToLatest()
+	if !errors.Is(err, ErrInjected) {
+		t.Fatalf("expected ErrInjected, got %v", err)
+	}
+
+	events := c.Events()
+	kinds := []EventKind{}
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+
+	expected := []EventKind{EventBegin, EventExec, EventExec, EventRollback}
+	if len(kinds) != len(expected) {
+		t.Fatalf("unexpected events %v", kinds)
+	}
+
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Fatalf("unexpected events %v", kinds)
+		}
+	}
+
+	if len(c.Committed()) != 0 || len(c.Versions()) != 0 {
+		t.Error("failed migration should leave nothing")
+	}
+}
+
+func TestRecordingConnectionFailOnStatement(t *testing.T) {
+	c := NewRecordingConnection()
+	c.FailOnStatement(2, errors.New("boom"))
+
+	tx, _ := c.Tx()
+	if err := tx.Exec("SELECT ?", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Exec("SELECT 2"); err == nil || err.Error() != "boom" {
+		t.Errorf("second statement should fail, got %v", err)
+	}
+
+	tx.Commit()
+	c.Exec("SELECT 3")
+
+	if committed := c.Committed(); len(committed) != 2 || committed[1] != "SELECT 3" {
+		t.Errorf("unexpected committed statements %q", committed)
+	}
+
+	if e := c.Events()[1]; e.Values[0] != 1 || e.Tx != 1 {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
+
+func TestRecordingTransactionConcurrentUse(t *testing.T) {
+	c := NewRecordingConnection()
+	tx, err := c.Tx()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx.Exec("INSERT INTO users VALUES (1);")
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tx.Commit()
+	}()
+	wg.Wait()
+
+	committed := false
+	for _, e := range c.Events() {
+		if e.Kind == EventCommit {
+			committed = true
+		}
+
+		if e.Kind == EventExec && committed {
+			t.Fatal("statement is recorded after commit")
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package migotest helps to test migrations and code that uses migo.
+package migotest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/walkline/migo"
+)
+
+// ErrSnapshotNotSupported is returned by SchemaSnapshot when connection
+// implements neither migo.SchemaInspector nor migo.SchemaDumper.
+var ErrSnapshotNotSupported = errors.New("connection can't describe its schema")
+
+// Snapshot returns description of the database schema,
+// snapshots of equal schemas are equal.
+type Snapshot func(c migo.Connection) (string, error)
+
+// SchemaSnapshot describes schema with migo.SchemaInspector
+// or migo.SchemaDumper of the connection.
+func SchemaSnapshot(c migo.Connection) (string, error) {
+	if i, ok := c.(migo.SchemaInspector); ok {
+		s, err := i.InspectSchema()
+		if err != nil {
+			return "", err
+		}
+
+		return s.String(), nil
+	}
+
+	if d, ok := c.(migo.SchemaDumper); ok {
+		return d.DumpSchema()
+	}
+
+	return "", ErrSnapshotNotSupported
+}
+
+// RoundTripResult is a result of checking a single migration.
+type RoundTripResult struct {
+	Version string
+	// Residue lists lines of schema snapshot that differ before Up and
+	// after Down, lines starting with `+` are left by Down, lines starting
+	// with `-` are removed by it.
+	Residue string
+	// Skipped is true for irreversible migrations, their Down is not run.
+	Skipped bool
+	Err     error
+}
+
+// Failed returns true when migration failed or its Down left residue.
+func (r RoundTripResult) Failed() bool {
+	return r.Err != nil || r.Residue != ""
+}
+
+// RoundTrip applies migrations one by one, checks that Down of every
+// migration reverts its Up and applies it again, so the next migration
+// is checked on top of it.
+type RoundTrip struct {
+	// Connect opens connection to an empty database.
+	Connect func() (migo.Connection, error)
+	Loaders []migo.MigrationLoader
+	// Snapshot describes schema, SchemaSnapshot by default.
+	Snapshot Snapshot
+}
+
+// Run checks migrations in the order of versions. Checking stops
+// at the first failed migration, because next ones can't be applied
+// on top of it.
+func (rt *RoundTrip) Run() ([]RoundTripResult, error) {
+	snapshot := rt.Snapshot
+	if snapshot == nil {
+		snapshot = SchemaSnapshot
+	}
+
+	c, err := rt.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []migo.Migration{}
+	for _, loader := range rt.Loaders {
+		ms, err := loader.Load()
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, ms...)
+	}
+
+	sort.SliceStable(migrations, func(i, j int) bool {
+		left, right := migrations[i].Version(), migrations[j].Version()
+		return right.GreaterThan(&left)
+	})
+
+	results := []RoundTripResult{}
+	for _, m := range migrations {
+		res := roundTrip(c, m, snapshot)
+		results = append(results, res)
+
+		if res.Failed() {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func roundTrip(c migo.Connection, m migo.Migration, snapshot Snapshot) (res RoundTripResult) {
+	res.Version = m.Version().String()
+	m.SetConnection(c)
+
+	if i, ok := m.(migo.Irreversible); ok && i.Irreversible() {
+		res.Skipped = true
+		res.Err = wrap("up", m.Up())
+		return res
+	}
+
+	before, err := snapshot(c)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	if res.Err = wrap("up", m.Up()); res.Err != nil {
+		return res
+	}
+
+	if res.Err = wrap("down", m.Down()); res.Err != nil {
+		return res
+	}
+
+	after, err := snapshot(c)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Residue = lineDiff(before, after)
+	if res.Residue != "" {
+		return res
+	}
+
+	res.Err = wrap("second up", m.Up())
+
+	return res
+}
+
+func wrap(step string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w", step, err)
+}
+
+// lineDiff returns lines that are only in `after` with `+` prefix
+// and lines that are only in `before` with `-` prefix.
+func lineDiff(before, after string) string {
+	count := map[string]int{}
+	for _, line := range strings.Split(before, "\n") {
+		count[line]++
+	}
+
+	added := []string{}
+	for _, line := range strings.Split(after, "\n") {
+		if count[line] > 0 {
+			count[line]--
+			continue
+		}
+
+		added = append(added, "+ "+line)
+	}
+
+	removed := []string{}
+	for _, line := range strings.Split(before, "\n") {
+		if count[line] > 0 {
+			count[line]--
+			removed = append(removed, "- "+line)
+		}
+	}
+
+	diff := append(removed, added...)
+	if len(diff) == 0 {
+		return ""
+	}
+
+	return strings.Join(diff, "\n") + "\n"
+}
+
+// CheckRoundTrip runs RoundTrip on the connection and reports every
+// failed migration as an error of the test.
+func CheckRoundTrip(t testing.TB, connect func() (migo.Connection, error), loaders ...migo.MigrationLoader) {
+	t.Helper()
+
+	rt := &RoundTrip{
+		Connect: connect,
+		Loaders: loaders,
+	}
+
+	results, err := rt.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, res := range results {
+		if res.Err != nil {
+			t.Errorf("migration '%s': %v", res.Version, res.Err)
+		} else if res.Residue != "" {
+			t.Errorf("migration '%s': down doesn't revert up:\n%s", res.Version, res.Residue)
+		}
+	}
+}
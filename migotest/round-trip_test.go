@@ -0,0 +1,98 @@
+package migotest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/walkline/migo"
+)
+
+// schemaConnection keeps names of created tables.
+type schemaConnection struct {
+	tables map[string]bool
+}
+
+func (c *schemaConnection) Exec(sql string, values ...interface{}) error {
+	fields := strings.Fields(sql)
+	switch {
+	case strings.HasPrefix(sql, "CREATE TABLE"):
+		c.tables[fields[2]] = true
+	case strings.HasPrefix(sql, "DROP TABLE"):
+		delete(c.tables, fields[2])
+	}
+
+	return nil
+}
+
+func (c *schemaConnection) LoadVersions() ([]string, error) { return []string{"0-null"}, nil }
+func (c *schemaConnection) SetVersion(v string) error       { return nil }
+func (c *schemaConnection) Tx() (migo.Transaction, error)   { return c, nil }
+func (c *schemaConnection) Commit() error                   { return nil }
+func (c *schemaConnection) Rollback() error                 { return nil }
+func (c *schemaConnection) InspectSchema() (*migo.Schema, error) {
+	s := &migo.Schema{}
+	for name := range c.tables {
+		s.Tables = append(s.Tables, migo.Table{Name: name})
+	}
+
+	return s, nil
+}
+
+type migration struct {
+	c            migo.Connection
+	v            migo.Version
+	up, down     string
+	irreversible bool
+}
+
+func newMigration(v, up, down string) *migration {
+	ver, _ := migo.VersionFromString(v)
+	return &migration{v: *ver, up: up, down: down}
+}
+
+func (m *migration) SetConnection(c migo.Connection) { m.c = c }
+func (m *migration) Version() migo.Version           { return m.v }
+func (m *migration) SetVersion(v *migo.Version)      { m.v = *v }
+func (m *migration) Up() error                       { return m.c.Exec(m.up) }
+func (m *migration) Down() error                     { return m.c.Exec(m.down) }
+func (m *migration) Irreversible() bool              { return m.irreversible }
+
+func TestRoundTrip(t *testing.T) {
+	loader := &migo.GoMigrationLoader{}
+	loader.Add(newMigration("2-orders", "CREATE TABLE orders", "DROP TABLE users"))
+	loader.Add(newMigration("1-users", "CREATE TABLE users", "DROP TABLE users"))
+	loader.Add(newMigration("3-items", "CREATE TABLE items", "DROP TABLE items"))
+
+	rt := &RoundTrip{
+		Connect: func() (migo.Connection, error) {
+			return &schemaConnection{tables: map[string]bool{}}, nil
+		},
+		Loaders: []migo.MigrationLoader{loader},
+	}
+
+	results, err := rt.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 || results[0].Failed() {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	if results[1].Residue != "- table users\n+ table orders\n" {
+		t.Errorf("unexpected residue:\n%s", results[1].Residue)
+	}
+}
+
+func TestRoundTripSkipsIrreversible(t *testing.T) {
+	irreversible := newMigration("2-delete", "DELETE FROM users", "")
+	irreversible.irreversible = true
+
+	loader := &migo.GoMigrationLoader{}
+	loader.Add(newMigration("1-users", "CREATE TABLE users", "DROP TABLE users"))
+	loader.Add(irreversible)
+
+	CheckRoundTrip(t, func() (migo.Connection, error) {
+		return &schemaConnection{tables: map[string]bool{}}, nil
+	}, loader)
+}
@@ -3,7 +3,10 @@ package migo
 import (
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -14,8 +17,35 @@ type Migrate struct {
 	c                Connection
 	loaders          []MigrationLoader
 	migrationsLoaded bool
+	out              io.Writer
+	gitCommit        string
+	outOfOrder       OutOfOrderPolicy
+	hooks            Hooks
+	sqlHooks         []SQLHook
+	tagFilter        TagFilter
+	skipped          []SkippedMigration
 }
 
+// OutOfOrderPolicy defines what UpToLatest does with migrations that are
+// not applied while migrations with greater versions are, e.g. ones
+// merged from a long living branch.
+type OutOfOrderPolicy int
+
+const (
+	// OutOfOrderError stops UpToLatest with ErrOutOfOrder before anything is applied.
+	OutOfOrderError OutOfOrderPolicy = iota
+	// OutOfOrderWarn prints a warning and applies them before new migrations.
+	OutOfOrderWarn
+	// OutOfOrderApply applies them before new migrations without a warning.
+	OutOfOrderApply
+	// OutOfOrderIgnore leaves them not applied.
+	OutOfOrderIgnore
+)
+
+// ErrOutOfOrder is returned by UpToLatest when there are migrations
+// out of order and policy is OutOfOrderError.
+var ErrOutOfOrder = errors.New("migrations out of order")
+
 // NewMigrate creates new struct that can start migration.
 // `c` is a connection to database, you can use gorm connection.
 // `loaders` is migrations loaders, it can be `sql` or `go` migrations loader
@@ -31,6 +61,25 @@ func (m *Migrate) SetConncetion(c Connection) {
 	m.c = c
 }
 
+// SetOutOfOrder sets what UpToLatest does with not applied migrations
+// that have versions lower than the last applied one, OutOfOrderError by default.
+func (m *Migrate) SetOutOfOrder(p OutOfOrderPolicy) {
+	m.outOfOrder = p
+}
+
+// SetOutput sets writer for progress messages, by default it is stdout.
+func (m *Migrate) SetOutput(w io.Writer) {
+	m.out = w
+}
+
+func (m *Migrate) output() io.Writer {
+	if m.out == nil {
+		return os.Stdout
+	}
+
+	return m.out
+}
+
 // Add adds migrations to the pool of pending migrations
 func (m *Migrate) Add(migration Migration) error {
 	m.migrations = append(m.migrations, migration)
@@ -39,7 +88,9 @@ func (m *Migrate) Add(migration Migration) error {
 
 // UpToLatest loads all needed migrations,
 // filters migrations that already applied,
-// and starts migration process.
+// and starts migration process. Migrations out of order
+// are handled according to SetOutOfOrder policy. Migrations
+// left out by SetTagFilter are printed as skipped.
 func (m *Migrate) UpToLatest() error {
 	err := m.loadMigrations()
 	if err != nil {
@@ -56,32 +107,65 @@ func (m *Migrate) UpToLatest() error {
 		return err
 	}
 
+	appliedVers, err = m.recordSuperseders(appliedVers)
+	if err != nil {
+		return err
+	}
+
+	graph, err := newDependencyGraph(m.migrations, appliedVers)
+	if err != nil {
+		return err
+	}
+
 	lastVer := GreatestVersion(appliedVers)
 
-	m.ensureThatMigrationsNotLost(lastVer, appliedVers)
+	lost := m.lostMigrations(lastVer, appliedVers)
+	migrationsToApply := append([]Migration{}, m.migrationsAfter(lastVer)...)
+	migrationsToApply = append(migrationsToApply, m.pendingDependents(lastVer, appliedVers)...)
+
+	if len(lost) > 0 {
+		lostVers := make([]string, len(lost))
+		for i, mig := range lost {
+			lostVers[i] = mig.Version().String()
+		}
+
+		switch m.outOfOrder {
+		case OutOfOrderError:
+			return fmt.Errorf("%w: %s not applied, but '%s' is", ErrOutOfOrder, strings.Join(lostVers, ", "), lastVer)
+		case OutOfOrderWarn:
+			fmt.Fprintf(m.output(), "Warning: %d migration(s) out of order will be applied: %s\n", len(lost), strings.Join(lostVers, ", "))
+			migrationsToApply = append(migrationsToApply, lost...)
+		case OutOfOrderApply:
+			migrationsToApply = append(migrationsToApply, lost...)
+		}
+	}
+
+	migrationsToApply, err = graph.order(m.sort(migrationsToApply, true), appliedVers)
+	if err != nil {
+		return err
+	}
 
-	migrationsToApply := m.migrationsAfter(lastVer)
-	fmt.Printf("Going to apply %d migration(s)...\n", len(migrationsToApply))
+	m.printSkipped(appliedVers)
+	fmt.Fprintf(m.output(), "Going to apply %d migration(s)...\n", len(migrationsToApply))
 
-	for _, migration := range migrationsToApply {
-		fmt.Printf("Applying '%s' migration... \n", migration.Version())
+	err = m.runAll(DirectionUp, migrationsToApply, func(migration Migration) error {
+		fmt.Fprintf(m.output(), "Applying '%s' migration... \n", migration.Version())
 		start := time.Now()
 
-		migration.SetConnection(m.c)
-		err := migration.Up()
+		err := m.up(migration)
 		if err != nil {
 			return err
 		}
 
-		err = m.c.SetVersion(migration.Version().String())
-		if err != nil {
-			return err
-		}
+		fmt.Fprintf(m.output(), "Applied '%s'! Duration: %v.\n\n", migration.Version(), time.Since(start))
 
-		fmt.Printf("Applied '%s'! Duration: %v.\n\n", migration.Version(), time.Since(start))
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("Database up to date!")
+	fmt.Fprintln(m.output(), "Database up to date!")
 
 	m.migrations = []Migration{}
 
@@ -89,7 +173,8 @@ func (m *Migrate) UpToLatest() error {
 }
 
 // DownWithSteps runs migrations to downgrade database version.
-// `steps` is number of latest migrations that needs to be unapplied.
+// `steps` is number of latest applied migrations that needs to be unapplied.
+// Nothing is discarded when one of them is irreversible, ErrIrreversible is returned.
 func (m *Migrate) DownWithSteps(steps int) error {
 	err := m.loadMigrations()
 	if err != nil {
@@ -108,55 +193,148 @@ func (m *Migrate) DownWithSteps(steps int) error {
 
 	lastVer := GreatestVersion(appliedVers)
 
-	migrationsToApply := m.migrationsBefore(lastVer)
+	applied := map[string]bool{}
+	for _, v := range appliedVers {
+		applied[v.StringWithoutName()] = true
+	}
+
+	// migrations out of order may be left not applied, see SetOutOfOrder
+	migrationsToApply := []Migration{}
+	for _, migration := range m.migrationsBefore(lastVer) {
+		if applied[migration.Version().StringWithoutName()] {
+			migrationsToApply = append(migrationsToApply, migration)
+		}
+	}
+
 	migrationsToApplyCount := steps
 	if steps > len(migrationsToApply) {
 		migrationsToApplyCount = len(migrationsToApply)
 	}
 
-	fmt.Printf("Going to discard %d migration(s)...\n", len(migrationsToApply))
-	for i := 0; i < migrationsToApplyCount; i++ {
-		migration := migrationsToApply[i]
+	for _, migration := range migrationsToApply[:migrationsToApplyCount] {
+		if isIrreversible(migration) {
+			return fmt.Errorf("%w: '%s' can't be discarded, nothing was discarded", ErrIrreversible, migration.Version())
+		}
+	}
 
-		fmt.Printf("Discarding '%s' migration... \n", migration.Version())
+	fmt.Fprintf(m.output(), "Going to discard %d migration(s)...\n", len(migrationsToApply))
 
-		migration.SetConnection(m.c)
-		err := migration.Down()
+	return m.runAll(DirectionDown, migrationsToApply[:migrationsToApplyCount], func(migration Migration) error {
+		fmt.Fprintf(m.output(), "Discarding '%s' migration... \n", migration.Version())
+
+		err := m.down(migration)
 		if err != nil {
 			return err
 		}
 
-		err = m.c.SetVersion(migration.Version().String())
-		if err != nil {
-			return err
+		fmt.Fprintf(m.output(), "Discarded '%s'!\n\n", migration.Version())
+
+		return nil
+	})
+}
+
+// ErrIrreversible is returned when irreversible migration has to be discarded.
+var ErrIrreversible = errors.New("migration is irreversible")
+
+func isIrreversible(migration Migration) bool {
+	i, ok := unwrapMigration(migration).(Irreversible)
+	return ok && i.Irreversible()
+}
+
+// up applies migration, marks it as applied and writes history.
+func (m *Migrate) up(migration Migration) error {
+	return m.runEach(DirectionUp, migration, func() error {
+		start := time.Now()
+
+		migration.SetConnection(m.c)
+		err := migration.Up()
+		if err == nil {
+			err = m.recordVersion(migration, time.Since(start))
+		}
+
+		return m.recordHistory(migration.Version().String(), HistoryUp, start, err)
+	})
+}
+
+// down discards migration, removes its version and writes history.
+func (m *Migrate) down(migration Migration) error {
+	return m.runEach(DirectionDown, migration, func() error {
+		start := time.Now()
+
+		migration.SetConnection(m.c)
+		err := migration.Down()
+		if err == nil {
+			err = m.removeVersion(migration.Version().String())
 		}
 
-		fmt.Printf("Discarded '%s'!\n\n", migration.Version())
+		return m.recordHistory(migration.Version().String(), HistoryDown, start, err)
+	})
+}
+
+// removeVersion marks version as not applied. Connections that don't
+// implement VersionRemover receive SetVersion call, as it was before.
+func (m *Migrate) removeVersion(v string) error {
+	if remover, ok := m.c.(VersionRemover); ok {
+		return remover.RemoveVersion(v)
 	}
 
-	return nil
+	return m.c.SetVersion(v)
 }
 
-func (m *Migrate) loadMigrations() error {
-	if m.migrationsLoaded {
-		return nil
+// recordVersion marks migration as applied, connections that implement
+// VersionRecorder also receive duration and checksum of migration.
+func (m *Migrate) recordVersion(migration Migration, d time.Duration) error {
+	recorder, ok := m.c.(VersionRecorder)
+	if !ok {
+		return m.c.SetVersion(migration.Version().String())
+	}
+
+	r := VersionRecord{
+		Version:  migration.Version().String(),
+		Duration: d,
 	}
 
-	for _, loader := range m.loaders {
-		migrations, err := loader.Load()
+	if c, ok := unwrapMigration(migration).(Checksummer); ok {
+		sum, err := c.Checksum()
 		if err != nil {
 			return err
 		}
+		r.Checksum = sum
+	}
+
+	return recorder.RecordVersion(r)
+}
 
-		for _, migration := range migrations {
-			err = m.Add(migration)
+// loadMigrations loads migrations and sql hooks once, drops superseded
+// migrations and ones left out by TagFilter.
+func (m *Migrate) loadMigrations() error {
+	if !m.migrationsLoaded {
+		for _, loader := range m.loaders {
+			migrations, err := loader.Load()
 			if err != nil {
 				return err
 			}
+
+			if hl, ok := loader.(HookLoader); ok {
+				hooks, err := hl.LoadHooks()
+				if err != nil {
+					return err
+				}
+				m.sqlHooks = append(m.sqlHooks, hooks...)
+			}
+
+			for _, migration := range migrations {
+				err = m.Add(migration)
+				if err != nil {
+					return err
+				}
+			}
 		}
+
+		m.migrationsLoaded = true
 	}
 
-	m.migrationsLoaded = true
+	m.migrations = m.filterTags(dropSuperseded(m.migrations))
 
 	return nil
 }
@@ -164,8 +342,8 @@ func (m *Migrate) loadMigrations() error {
 func (m *Migrate) migrationsAfter(v *Version) []Migration {
 	ms := m.sort(m.migrations, true)
 	for i := range ms {
-		if m.migrations[i].Version().GreaterThan(v) {
-			return m.migrations[i:]
+		if ms[i].Version().GreaterThan(v) {
+			return ms[i:]
 		}
 	}
 
@@ -175,9 +353,9 @@ func (m *Migrate) migrationsAfter(v *Version) []Migration {
 func (m *Migrate) migrationsBefore(v *Version) []Migration {
 	ms := m.sort(m.migrations, false)
 	for i := range ms {
-		otherV := m.migrations[i].Version()
+		otherV := ms[i].Version()
 		if v.GreaterThanOrEqual(&otherV) {
-			return m.migrations[i:]
+			return ms[i:]
 		}
 	}
 
@@ -186,8 +364,8 @@ func (m *Migrate) migrationsBefore(v *Version) []Migration {
 
 func (m *Migrate) sort(ms []Migration, asc bool) []Migration {
 	sort.SliceStable(ms, func(i, j int) bool {
-		left := m.migrations[i].Version()
-		right := m.migrations[j].Version()
+		left := ms[i].Version()
+		right := ms[j].Version()
 
 		if asc {
 			return right.GreaterThan(&left)
@@ -198,28 +376,35 @@ func (m *Migrate) sort(ms []Migration, asc bool) []Migration {
 	return ms
 }
 
-func (m *Migrate) ensureThatMigrationsNotLost(lastVersion *Version, appliedVersions []Version) {
-	migsBefore := m.migrationsBefore(lastVersion)
+// pendingDependents returns not applied migrations with dependencies
+// that have version lower or equal to the last applied one.
+func (m *Migrate) pendingDependents(lastVersion *Version, appliedVersions []Version) []Migration {
+	applied := map[string]bool{}
+	for _, v := range appliedVersions {
+		applied[v.String()] = true
+	}
 
-	for _, mig := range migsBefore {
-		found := false
-		for _, v := range appliedVersions {
-			if v.String() == mig.Version().String() {
-				found = true
-				break
-			}
-		}
-
-		if !found {
-			panic(fmt.Sprintf("version '%s' not found in applied migrations", mig.Version()))
+	pending := []Migration{}
+	for _, mig := range m.migrationsBefore(lastVersion) {
+		if len(migrationDependencies(mig)) > 0 && !applied[mig.Version().String()] {
+			pending = append(pending, mig)
 		}
 	}
+
+	return pending
 }
 
+// lostMigrations returns not applied migrations below the last applied
+// version. Migrations with dependencies are not lost, they are applied
+// after their dependencies.
 func (m *Migrate) lostMigrations(lastVersion *Version, appliedVersions []Version) []Migration {
 	migsBefore := m.migrationsBefore(lastVersion)
 	lost := []Migration{}
 	for _, mig := range migsBefore {
+		if len(migrationDependencies(mig)) > 0 {
+			continue
+		}
+
 		found := false
 		for _, v := range appliedVersions {
 			if v.String() == mig.Version().String() {
@@ -238,6 +423,9 @@ func (m *Migrate) lostMigrations(lastVersion *Version, appliedVersions []Version
 	return lost
 }
 
+// ApplyLostAndPanic applies lost migrations with a delay and panics.
+//
+// Deprecated: use SetOutOfOrder(OutOfOrderWarn) and UpToLatest.
 func (m *Migrate) ApplyLostAndPanic() error {
 	err := m.applyLost(5 * time.Second)
 	if err != nil {
@@ -247,6 +435,8 @@ func (m *Migrate) ApplyLostAndPanic() error {
 	panic("remove ApplyLostAndPanic code")
 }
 
+// ApplyLostIfAny applies migrations below the last applied version
+// that are not applied. UpToLatest does it with OutOfOrderApply policy.
 func (m *Migrate) ApplyLostIfAny() error {
 	return m.applyLost(0)
 }
@@ -271,31 +461,30 @@ func (m *Migrate) applyLost(delayBetweenMigrations time.Duration) error {
 
 	migrationsToApply := m.lostMigrations(lastVer, appliedVers)
 
-	fmt.Printf("Found %d lost migration(s)...\n", len(migrationsToApply))
+	fmt.Fprintf(m.output(), "Found %d lost migration(s)...\n", len(migrationsToApply))
 
-	for _, migration := range migrationsToApply {
-		fmt.Printf("Applying '%s' migration... \n", migration.Version())
+	err = m.runAll(DirectionUp, migrationsToApply, func(migration Migration) error {
+		fmt.Fprintf(m.output(), "Applying '%s' migration... \n", migration.Version())
 		if delayBetweenMigrations > 0 {
 			time.Sleep(delayBetweenMigrations)
 		}
 
 		start := time.Now()
 
-		migration.SetConnection(m.c)
-		err := migration.Up()
+		err := m.up(migration)
 		if err != nil {
 			return err
 		}
 
-		err = m.c.SetVersion(migration.Version().String())
-		if err != nil {
-			return err
-		}
+		fmt.Fprintf(m.output(), "Applied '%s'! Duration: %v.\n\n", migration.Version(), time.Since(start))
 
-		fmt.Printf("Applied '%s'! Duration: %v.\n\n", migration.Version(), time.Since(start))
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("Lost migrations applied!")
+	fmt.Fprintln(m.output(), "Lost migrations applied!")
 
 	m.migrations = []Migration{}
 
@@ -1,8 +1,14 @@
 package migo
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
 	"os"
 	"testing"
+
+	"github.com/walkline/migo/sqlscanner"
 )
 
 type ConnectionMock struct {
@@ -183,3 +189,228 @@ func TestDownWithStepsSQLMigration(t *testing.T) {
 		t.Error("bad sql")
 	}
 }
+
+type DialectConnectionMock struct {
+	ConnectionMock
+	dialect sqlscanner.Dialect
+}
+
+func (c *DialectConnectionMock) Dialect() sqlscanner.Dialect {
+	return c.dialect
+}
+
+func (c *DialectConnectionMock) Tx() (Transaction, error) {
+	return c, nil
+}
+
+func TestUpSQLMigrationWithConnectionDialect(t *testing.T) {
+	upFile, err := os.Create("up1.sql")
+	if err != nil {
+		t.Error(err)
+	}
+
+	downFile, err := os.Create("down1.sql")
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		os.Remove("up1.sql")
+		os.Remove("down1.sql")
+	}()
+
+	upFile.WriteString("CREATE TABLE t (id INT);\nINSERT INTO t VALUES (1);\nGO\nSELECT 1;\n")
+	upFile.Seek(0, os.SEEK_SET)
+
+	c := &DialectConnectionMock{dialect: sqlscanner.DialectSQLServer}
+
+	m := &SQLMigration{
+		UpFile:   upFile,
+		DownFile: downFile,
+	}
+	m.SetConnection(c)
+
+	err = m.Up()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(c.sqls) != 2 {
+		t.Fatal("expected 2 batches, have", len(c.sqls))
+	}
+
+	if c.sqls[0] != "CREATE TABLE t (id INT);\nINSERT INTO t VALUES (1);" {
+		t.Error("bad sql")
+	}
+
+	if c.sqls[1] != "SELECT 1;" {
+		t.Error("bad sql")
+	}
+}
+
+type RecorderConnectionMock struct {
+	ConnectionMock
+	records []VersionRecord
+}
+
+func (c *RecorderConnectionMock) RecordVersion(r VersionRecord) error {
+	c.records = append(c.records, r)
+	return c.SetVersion(r.Version)
+}
+
+func (c *RecorderConnectionMock) Tx() (Transaction, error) {
+	return c, nil
+}
+
+func TestUpToLatestRecordsChecksum(t *testing.T) {
+	err := ioutil.WriteFile("1-name.up.sql", []byte("UP SQL 1;"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("1-name.up.sql")
+
+	c := &RecorderConnectionMock{ConnectionMock: ConnectionMock{v: "0-null"}}
+
+	m := Migrate{}
+	m.SetConncetion(c)
+	m.SetOutput(ioutil.Discard)
+
+	m1 := &SQLMigration{UpPath: "1-name.up.sql"}
+	v, _ := VersionFromString("1-name")
+	m1.SetVersion(v)
+	m.Add(m1)
+
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte("UP SQL 1;"))
+	if len(c.records) != 1 || c.records[0].Version != "1-name" || c.records[0].Checksum != hex.EncodeToString(sum[:]) {
+		t.Error("unexpected records", c.records)
+	}
+}
+
+type IrreversibleMigrationMock struct {
+	SQLMigration
+}
+
+func (m *IrreversibleMigrationMock) Irreversible() bool {
+	return true
+}
+
+func TestDownWithStepsIrreversible(t *testing.T) {
+	c := &HistoryConnectionMock{versions: []string{"1-create", "2-delete", "3-add"}}
+
+	m := NewMigrate(c)
+	m.SetOutput(ioutil.Discard)
+	for i, name := range []string{"1-create", "2-delete", "3-add"} {
+		var mig Migration = &SQLMigration{Path: "unused.sql"}
+		if i == 1 {
+			mig = &IrreversibleMigrationMock{}
+		}
+
+		v, _ := VersionFromString(name)
+		mig.SetVersion(v)
+		m.Add(mig)
+	}
+
+	err := m.DownWithSteps(2)
+	if !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("expected ErrIrreversible, got %v", err)
+	}
+
+	if len(c.versions) != 3 {
+		t.Error("nothing should be discarded")
+	}
+}
+
+func TestSQLMigrationIrreversible(t *testing.T) {
+	ioutil.WriteFile("1-name.up.sql", []byte("-- migo:irreversible\nDELETE FROM users;"), 0644)
+	ioutil.WriteFile("2-name.sql", []byte("-- migo:up\nDELETE FROM users;"), 0644)
+	ioutil.WriteFile("3-name.sql", []byte("-- migo:up\nSELECT 1;\n-- migo:down\nSELECT 2;"), 0644)
+	defer removeFiles("1-name.up.sql", "2-name.sql", "3-name.sql")
+
+	cases := map[*SQLMigration]bool{
+		{UpPath: "1-name.up.sql", DownPath: "1-name.up.sql"}: true,
+		{UpPath: "1-name.up.sql"}:                            true,
+		{Path: "2-name.sql"}:                                 true,
+		{Path: "3-name.sql"}:                                 false,
+	}
+
+	for mig, expected := range cases {
+		if mig.Irreversible() != expected {
+			t.Errorf("%+v: expected irreversible %v", mig, expected)
+		}
+	}
+}
+
+func TestUpToLatestOutOfOrder(t *testing.T) {
+	cases := map[OutOfOrderPolicy][]string{
+		OutOfOrderWarn:   {"UP 2-lost", "UP 4-new"},
+		OutOfOrderApply:  {"UP 2-lost", "UP 4-new"},
+		OutOfOrderIgnore: {"UP 4-new"},
+	}
+
+	for policy, expected := range cases {
+		c := &HistoryConnectionMock{versions: []string{"1-first", "3-third"}}
+		m := newDependentMigrate(c, map[string][]string{
+			"1-first": nil,
+			"2-lost":  nil,
+			"3-third": nil,
+			"4-new":   nil,
+		})
+		m.SetOutOfOrder(policy)
+
+		err := m.UpToLatest()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(c.sqls) != len(expected) {
+			t.Errorf("policy %d: unexpected sqls %q", policy, c.sqls)
+			continue
+		}
+
+		for i := range expected {
+			if c.sqls[i] != expected[i] {
+				t.Errorf("policy %d: unexpected sqls %q", policy, c.sqls)
+			}
+		}
+	}
+
+	c := &HistoryConnectionMock{versions: []string{"1-first", "3-third"}}
+	m := newDependentMigrate(c, map[string][]string{
+		"1-first": nil,
+		"2-lost":  nil,
+		"3-third": nil,
+		"4-new":   nil,
+	})
+
+	err := m.UpToLatest()
+	if !errors.Is(err, ErrOutOfOrder) {
+		t.Errorf("expected ErrOutOfOrder, got %v", err)
+	}
+
+	if len(c.sqls) != 0 {
+		t.Error("nothing should be applied")
+	}
+}
+
+func TestDownWithStepsSkipsNotApplied(t *testing.T) {
+	c := &HistoryConnectionMock{versions: []string{"1-first", "3-third"}}
+	m := newDependentMigrate(c, map[string][]string{
+		"1-first": nil,
+		"2-lost":  nil,
+		"3-third": nil,
+	})
+
+	err := m.DownWithSteps(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.sqls) != 2 || c.sqls[0] != "DOWN 3-third" || c.sqls[1] != "DOWN 1-first" {
+		t.Errorf("only applied migrations should be discarded, got %q", c.sqls)
+	}
+}
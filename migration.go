@@ -1,7 +1,14 @@
 package migo
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/walkline/migo/sqlscanner"
 )
@@ -19,6 +26,66 @@ type Transaction interface {
 	Rollback() error
 }
 
+// Querier is implemented by connections that can read rows,
+// values of a row are keyed by column names.
+type Querier interface {
+	Query(sql string, values ...interface{}) ([]map[string]interface{}, error)
+}
+
+// VersionRecord describes applied migration.
+type VersionRecord struct {
+	Version  string
+	Duration time.Duration
+	Checksum string
+}
+
+// VersionRecorder is implemented by connections that can store
+// more information about applied migration than just a version.
+// When connection implements it, RecordVersion is used instead of SetVersion.
+type VersionRecorder interface {
+	RecordVersion(r VersionRecord) error
+}
+
+// VersionRemover is implemented by connections that can remove
+// applied version, it is used when migration is discarded.
+type VersionRemover interface {
+	RemoveVersion(v string) error
+}
+
+// Checksummer is implemented by migrations that can calculate
+// checksum of their content.
+type Checksummer interface {
+	Checksum() (string, error)
+}
+
+// Irreversible is implemented by migrations that may be impossible
+// to discard, e.g. ones that delete data. DownWithSteps stops with
+// ErrIrreversible before such migration.
+type Irreversible interface {
+	Irreversible() bool
+}
+
+// Superseder is implemented by migrations that replace all migrations
+// up to and including the returned version, e.g. baseline created by Squash.
+// Superseded migrations are ignored, databases that have them applied
+// get the superseder recorded as applied without running it.
+type Superseder interface {
+	Supersedes() string
+}
+
+// SchemaDumper is implemented by connections that can dump schema
+// of the database as sql script, it is used by Squash.
+type SchemaDumper interface {
+	DumpSchema() (string, error)
+}
+
+// DialectConnection is implemented by connections that know
+// their sql dialect. Dialect defines how sql migrations are split
+// into statements.
+type DialectConnection interface {
+	Dialect() sqlscanner.Dialect
+}
+
 type Migration interface {
 	SetConnection(c Connection)
 	Version() Version
@@ -27,11 +94,45 @@ type Migration interface {
 	Down() error
 }
 
+// unwrapMigration returns migration wrapped by migo,
+// it should be used to check optional interfaces of migration.
+func unwrapMigration(m Migration) Migration {
+	for {
+		w, ok := m.(interface{ Unwrap() Migration })
+		if !ok {
+			return m
+		}
+		m = w.Unwrap()
+	}
+}
+
+// SQLMigration is a migration written in sql. Statements are read
+// from UpFile/DownFile when they are set, otherwise files at
+// UpPath/DownPath (or sections of Path) are read on every run,
+// so such migration can be applied many times, e.g. to different databases.
 type SQLMigration struct {
 	c        Connection
 	v        Version
 	UpFile   *os.File
 	DownFile *os.File
+	UpPath   string
+	DownPath string
+
+	// Path is a single file with `-- migo:up` and `-- migo:down` sections,
+	// it is used when UpPath and DownPath are empty.
+	Path string
+
+	// Dialect defines how files are split into statements.
+	// When it is empty dialect of the connection is used.
+	Dialect sqlscanner.Dialect
+
+	// Variables are substituted into statements before execution,
+	// see ExpandVariables. Statements are executed as is when it is nil.
+	Variables map[string]string
+
+	// header is read once by Load or on the first use of directives.
+	header    *sqlHeader
+	headerErr error
 }
 
 func (m *SQLMigration) SetConnection(c Connection) {
@@ -39,52 +140,81 @@ func (m *SQLMigration) SetConnection(c Connection) {
 }
 
 func (m *SQLMigration) Up() error {
-	defer m.UpFile.Close()
-	defer m.DownFile.Close()
+	defer m.closeFiles()
 
-	tx, err := m.c.Tx()
-	if err != nil {
+	if err := m.readHeader(); err != nil {
 		return err
 	}
 
-	scanner := sqlscanner.NewSQLScanner(m.UpFile)
-	query := ""
-	for scanner.Next(&query) {
-		err := tx.Exec(query)
+	if m.isSingleFile() {
+		s, err := readSQLSections(m.Path)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
+
+		return m.exec(bytes.NewReader(s.up))
 	}
 
-	if scanner.Error != nil {
-		tx.Rollback()
+	f, err := openSQLFile(m.UpFile, m.UpPath)
+	if err != nil {
 		return err
 	}
+	defer f.Close()
 
-	return tx.Commit()
+	return m.exec(f)
 }
 
 func (m *SQLMigration) Down() error {
-	defer m.UpFile.Close()
-	defer m.DownFile.Close()
+	defer m.closeFiles()
+
+	if err := m.readHeader(); err != nil {
+		return err
+	}
+
+	if m.isSingleFile() {
+		s, err := readSQLSections(m.Path)
+		if err != nil {
+			return err
+		}
+
+		if !s.hasDown {
+			return fmt.Errorf("%s: '%s' section not found", m.Path, SQLDownMarker)
+		}
+
+		return m.exec(bytes.NewReader(s.down))
+	}
+
+	f, err := openSQLFile(m.DownFile, m.DownPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return m.exec(f)
+}
 
+func (m *SQLMigration) exec(r io.Reader) error {
 	tx, err := m.c.Tx()
 	if err != nil {
 		return err
 	}
 
-	scanner := sqlscanner.NewSQLScanner(m.DownFile)
-	query := ""
-	for scanner.Next(&query) {
-		err := m.c.Exec(query)
+	scanner := m.newScanner(r)
+	for scanner.Scan() {
+		query, err := m.expand(scanner.Text())
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = tx.Exec(query)
 		if err != nil {
 			tx.Rollback()
 			return err
 		}
 	}
 
-	if scanner.Error != nil {
+	if err := scanner.Err(); err != nil {
 		tx.Rollback()
 		return err
 	}
@@ -92,6 +222,137 @@ func (m *SQLMigration) Down() error {
 	return tx.Commit()
 }
 
+// Irreversible returns true when down file (or section) is missing
+// or migration has `-- migo:irreversible` directive.
+func (m *SQLMigration) Irreversible() bool {
+	if m.isSingleFile() {
+		if m.readHeader() == nil && !m.header.hasDown {
+			return true
+		}
+	} else if m.DownFile == nil && m.DownPath == "" {
+		return true
+	}
+
+	return hasDirective(m.directives(), directiveIrreversible)
+}
+
+// Supersedes returns version of `-- migo:supersedes <version>` directive.
+func (m *SQLMigration) Supersedes() string {
+	for _, d := range m.directives() {
+		if d.name == directiveSupersedes && len(d.args) > 0 {
+			return d.args[0]
+		}
+	}
+
+	return ""
+}
+
+// Depends returns versions of `-- migo:depends <version>...` directives.
+func (m *SQLMigration) Depends() []string {
+	deps := []string{}
+	for _, d := range m.directives() {
+		if d.name == directiveDepends {
+			deps = append(deps, d.args...)
+		}
+	}
+
+	return deps
+}
+
+// directives returns directives of migration files. Files that can't
+// be read have no directives, Load and Up/Down return their error.
+func (m *SQLMigration) directives() []directive {
+	if m.readHeader() != nil {
+		return nil
+	}
+
+	return m.header.directives
+}
+
+// readHeader reads directives of migration files once.
+func (m *SQLMigration) readHeader() error {
+	if m.header != nil || m.headerErr != nil {
+		return m.headerErr
+	}
+
+	h := &sqlHeader{}
+	if m.isSingleFile() {
+		h, m.headerErr = readSQLHeader(m.Path, true)
+	} else {
+		for _, path := range []string{m.UpPath, m.DownPath} {
+			if path == "" || m.headerErr != nil {
+				continue
+			}
+
+			var fh *sqlHeader
+			fh, m.headerErr = readSQLHeader(path, false)
+			if fh != nil {
+				h.directives = append(h.directives, fh.directives...)
+			}
+		}
+	}
+
+	if m.headerErr == nil {
+		m.header = h
+	}
+
+	return m.headerErr
+}
+
+func (m *SQLMigration) isSingleFile() bool {
+	return m.Path != "" && m.UpFile == nil && m.UpPath == ""
+}
+
+// Checksum returns sha256 of up and down files. Returns empty checksum
+// when migration is created from files instead of paths.
+func (m *SQLMigration) Checksum() (string, error) {
+	if m.UpPath == "" && m.Path == "" {
+		return "", nil
+	}
+
+	h := sha256.New()
+	for _, path := range []string{m.UpPath, m.DownPath, m.Path} {
+		if path == "" {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (m *SQLMigration) closeFiles() {
+	if m.UpFile != nil {
+		m.UpFile.Close()
+	}
+
+	if m.DownFile != nil {
+		m.DownFile.Close()
+	}
+}
+
+func openSQLFile(f *os.File, path string) (*os.File, error) {
+	if f != nil {
+		return f, nil
+	}
+
+	if path == "" {
+		return nil, errors.New("sql migration has neither file nor path")
+	}
+
+	return os.Open(path)
+}
+
 func (m *SQLMigration) Version() Version {
 	return m.v
 }
@@ -99,3 +360,27 @@ func (m *SQLMigration) Version() Version {
 func (m *SQLMigration) SetVersion(v *Version) {
 	m.v = *v
 }
+
+func (m *SQLMigration) newScanner(r io.Reader) *sqlscanner.Scanner {
+	dialect := m.Dialect
+	if dialect == sqlscanner.DialectDefault {
+		if c, ok := m.c.(DialectConnection); ok {
+			dialect = c.Dialect()
+		}
+	}
+
+	return sqlscanner.NewDialectScanner(r, dialect)
+}
+
+func (m *SQLMigration) expand(query string) (string, error) {
+	if m.Variables == nil {
+		return query, nil
+	}
+
+	query, err := ExpandVariables(query, m.Variables)
+	if err != nil {
+		return "", fmt.Errorf("migration '%s': %w", m.v, err)
+	}
+
+	return query, nil
+}
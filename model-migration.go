@@ -0,0 +1,27 @@
+package migo
+
+import "errors"
+
+// ErrModelsNotSupported is returned when connection can't compare
+// models of the application with the database.
+var ErrModelsNotSupported = errors.New("connection can't compare models with the database")
+
+// ModelMigration is go code of migration that brings the database
+// to models of the application.
+type ModelMigration struct {
+	// Imports are import specs used by Up and Down, e.g. `"example.com/app/models"`.
+	Imports []string
+	// Up and Down are bodies of go migration methods.
+	Up   string
+	Down string
+	// Changes describe changes made by Up.
+	Changes []string
+}
+
+// ModelDiffer is implemented by connections that know models
+// of the application, e.g. gorm connection with registered models.
+type ModelDiffer interface {
+	// DiffModels compares models with the database, returns
+	// migration with empty Changes when they match.
+	DiffModels() (*ModelMigration, error)
+}
@@ -0,0 +1,5 @@
+package migo
+
+// Release is the version of migo, connections can store it
+// next to applied migrations.
+var Release = "0.2.0"
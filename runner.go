@@ -0,0 +1,392 @@
+package migo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FailurePolicy defines what Runner does when migration of a target fails.
+type FailurePolicy int
+
+const (
+	// ContinueOnFailure keeps migrating other targets.
+	ContinueOnFailure FailurePolicy = iota
+	// HaltOnFailure doesn't start new targets after the first failure,
+	// targets that are already in progress are finished.
+	HaltOnFailure
+)
+
+// Target is a database (or a schema) that Runner migrates.
+type Target struct {
+	Name       string
+	Connection Connection
+
+	// Variables are merged over variables of sql migrations,
+	// e.g. `{"schema": "tenant1"}`.
+	Variables map[string]string
+
+	// TagFilter overrides filter of Runner for the target,
+	// e.g. to run migrations tagged `eu` only on databases in Europe.
+	TagFilter *TagFilter
+}
+
+// TargetResult is a result of migrating a single target.
+type TargetResult struct {
+	Target string
+	// From is the greatest version before migration.
+	From string
+	// To is the greatest version after migration.
+	To       string
+	Applied  []string
+	Duration time.Duration
+	// Skipped is true when target wasn't migrated because of HaltOnFailure policy.
+	Skipped bool
+	Err     error
+}
+
+// RunReport contains results of all targets in the order they were given.
+type RunReport struct {
+	Results []TargetResult
+}
+
+// Failed returns results of targets that failed.
+func (r *RunReport) Failed() []TargetResult {
+	failed := []TargetResult{}
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+
+	return failed
+}
+
+// Err returns error that lists failed targets or nil if all targets succeeded.
+func (r *RunReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(failed))
+	for i, res := range failed {
+		msgs[i] = fmt.Sprintf("%s: %v", res.Target, res.Err)
+	}
+
+	return fmt.Errorf("%d of %d target(s) failed: %s", len(failed), len(r.Results), strings.Join(msgs, "; "))
+}
+
+// ErrSharedFiles is returned by Runner for sql migrations with UpFile or DownFile,
+// open files are closed after the first target, so they can't be applied to many targets.
+var ErrSharedFiles = errors.New("sql migration with open files can't be applied to many targets, use UpPath and DownPath")
+
+// Runner applies the same set of migrations to many targets,
+// e.g. to a schema per tenant. Versions are tracked by connection
+// of every target separately.
+type Runner struct {
+	targets     []Target
+	loaders     []MigrationLoader
+	parallelism int
+	policy      FailurePolicy
+	outOfOrder  OutOfOrderPolicy
+	hooks       Hooks
+	tagFilter   TagFilter
+	out         io.Writer
+}
+
+// NewRunner creates runner for `targets` with migrations from `loaders`.
+// By default targets are migrated one by one and failure of
+// a target doesn't stop others.
+func NewRunner(targets []Target, loaders ...MigrationLoader) *Runner {
+	return &Runner{
+		targets:     targets,
+		loaders:     loaders,
+		parallelism: 1,
+		policy:      ContinueOnFailure,
+	}
+}
+
+// SetParallelism sets how many targets can be migrated at the same time.
+func (r *Runner) SetParallelism(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	r.parallelism = n
+}
+
+// SetFailurePolicy sets what to do when migration of a target fails.
+func (r *Runner) SetFailurePolicy(p FailurePolicy) {
+	r.policy = p
+}
+
+// SetOutOfOrder sets policy for migrations out of order of every target,
+// see Migrate.SetOutOfOrder.
+func (r *Runner) SetOutOfOrder(p OutOfOrderPolicy) {
+	r.outOfOrder = p
+}
+
+// SetHooks sets go hooks that run for every target, they receive
+// connection of the target. See Migrate.SetHooks.
+func (r *Runner) SetHooks(h Hooks) {
+	r.hooks = h
+}
+
+// SetTagFilter sets which tagged migrations are applied to targets
+// without their own TagFilter, see Migrate.SetTagFilter.
+func (r *Runner) SetTagFilter(f TagFilter) {
+	r.tagFilter = f
+}
+
+// SetOutput sets writer for progress messages, by default it is stdout.
+// Messages are prefixed with a target name.
+func (r *Runner) SetOutput(w io.Writer) {
+	r.out = w
+}
+
+// UpToLatest loads migrations once and applies them to every target.
+// Returned error is not nil only when migrations can't be loaded,
+// failures of targets are reported in RunReport.
+func (r *Runner) UpToLatest() (*RunReport, error) {
+	migrations := []Migration{}
+	sqlHooks := []SQLHook{}
+	for _, loader := range r.loaders {
+		ms, err := loader.Load()
+		if err != nil {
+			return nil, errors.New("can't load migrations " + err.Error())
+		}
+
+		for _, migration := range ms {
+			if sqlMigration, ok := unwrapMigration(migration).(*SQLMigration); ok && (sqlMigration.UpFile != nil || sqlMigration.DownFile != nil) {
+				return nil, fmt.Errorf("%w: '%s'", ErrSharedFiles, migration.Version())
+			}
+		}
+
+		migrations = append(migrations, ms...)
+
+		if hl, ok := loader.(HookLoader); ok {
+			hooks, err := hl.LoadHooks()
+			if err != nil {
+				return nil, errors.New("can't load hooks " + err.Error())
+			}
+			sqlHooks = append(sqlHooks, hooks...)
+		}
+	}
+
+	// go migrations are shared between targets, so the same migration
+	// can't be applied to different targets at the same time
+	locks := make([]sync.Mutex, len(migrations))
+
+	out := r.out
+	if out == nil {
+		out = os.Stdout
+	}
+	outMu := &sync.Mutex{}
+
+	report := &RunReport{
+		Results: make([]TargetResult, len(r.targets)),
+	}
+
+	var (
+		halted   bool
+		haltedMu sync.Mutex
+		wg       sync.WaitGroup
+	)
+
+	jobs := make(chan int)
+	for w := 0; w < r.parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				target := r.targets[i]
+
+				haltedMu.Lock()
+				skip := halted
+				haltedMu.Unlock()
+
+				if skip {
+					report.Results[i] = TargetResult{Target: target.Name, Skipped: true}
+					continue
+				}
+
+				res := r.migrateTarget(target, migrations, sqlHooks, locks, &prefixWriter{
+					w:      out,
+					mu:     outMu,
+					prefix: "[" + target.Name + "] ",
+				})
+				report.Results[i] = res
+
+				if res.Err != nil && r.policy == HaltOnFailure {
+					haltedMu.Lock()
+					halted = true
+					haltedMu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range r.targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return report, nil
+}
+
+func (r *Runner) migrateTarget(target Target, migrations []Migration, sqlHooks []SQLHook, locks []sync.Mutex, out io.Writer) (res TargetResult) {
+	res.Target = target.Name
+	start := time.Now()
+
+	defer func() {
+		if p := recover(); p != nil {
+			res.Err = fmt.Errorf("%v", p)
+		}
+
+		res.Duration = time.Since(start)
+		res.To, _ = greatestAppliedVersion(target.Connection)
+	}()
+
+	from, err := greatestAppliedVersion(target.Connection)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.From = from
+
+	m := NewMigrate(target.Connection)
+	m.SetOutput(out)
+	m.SetOutOfOrder(r.outOfOrder)
+	m.SetHooks(r.hooks)
+	m.SetTagFilter(r.tagFilter)
+	if target.TagFilter != nil {
+		m.SetTagFilter(*target.TagFilter)
+	}
+	m.migrationsLoaded = true
+
+	for _, h := range sqlHooks {
+		if target.Variables != nil {
+			h.Variables = MergeVariables(h.Variables, target.Variables)
+		}
+		m.sqlHooks = append(m.sqlHooks, h)
+	}
+
+	for i, migration := range migrations {
+		tm := &targetMigration{
+			Migration: migration,
+			applied:   &res.Applied,
+		}
+
+		// sql migrations are cloned for every target, so they run in parallel
+		if sqlMigration, ok := unwrapMigration(migration).(*SQLMigration); ok {
+			clone := *sqlMigration
+			if target.Variables != nil {
+				clone.Variables = MergeVariables(clone.Variables, target.Variables)
+			}
+			tm.Migration = &clone
+		} else {
+			tm.mu = &locks[i]
+		}
+
+		m.Add(tm)
+	}
+
+	res.Err = m.UpToLatest()
+
+	return res
+}
+
+func greatestAppliedVersion(c Connection) (string, error) {
+	verStrs, err := c.LoadVersions()
+	if err != nil {
+		return "", err
+	}
+
+	vers, err := StringsToVersions(verStrs)
+	if err != nil {
+		return "", err
+	}
+
+	return GreatestVersion(vers).String(), nil
+}
+
+// targetMigration serializes usage of the wrapped migration when `mu`
+// is set, because connection is set right before it is applied.
+type targetMigration struct {
+	Migration
+	c       Connection
+	mu      *sync.Mutex
+	applied *[]string
+}
+
+func (m *targetMigration) Unwrap() Migration {
+	return m.Migration
+}
+
+func (m *targetMigration) SetConnection(c Connection) {
+	m.c = c
+}
+
+func (m *targetMigration) Up() error {
+	if m.mu != nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+	}
+
+	m.Migration.SetConnection(m.c)
+	err := m.Migration.Up()
+	if err == nil {
+		*m.applied = append(*m.applied, m.Version().String())
+	}
+
+	return err
+}
+
+func (m *targetMigration) Down() error {
+	if m.mu != nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+	}
+
+	m.Migration.SetConnection(m.c)
+	return m.Migration.Down()
+}
+
+// prefixWriter writes every line with a prefix, writes of
+// different prefixWriters sharing the same mutex are not mixed.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	buf := bytes.NewBuffer(nil)
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			buf.WriteString(w.prefix)
+		}
+		buf.Write(line)
+	}
+
+	_, err := w.w.Write(buf.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,221 @@
+package migo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type FailingConnectionMock struct {
+	ConnectionMock
+}
+
+func (c *FailingConnectionMock) Exec(sql string, values ...interface{}) error {
+	return errors.New("connection refused")
+}
+
+func (c *FailingConnectionMock) Tx() (Transaction, error) {
+	return c, nil
+}
+
+// BarrierConnectionMock waits in Exec until all targets execute
+// their statements, waiting is marked when it takes too long.
+type BarrierConnectionMock struct {
+	ConnectionMock
+	wg       *sync.WaitGroup
+	timedOut *int32
+}
+
+func (c *BarrierConnectionMock) Exec(sql string, values ...interface{}) error {
+	c.wg.Done()
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		atomic.StoreInt32(c.timedOut, 1)
+	}
+
+	return c.ConnectionMock.Exec(sql, values...)
+}
+
+func (c *BarrierConnectionMock) Tx() (Transaction, error) {
+	return c, nil
+}
+
+// WrappedMigrationMock wraps a migration like middleware of applications.
+type WrappedMigrationMock struct {
+	Migration
+}
+
+func (m *WrappedMigrationMock) Unwrap() Migration {
+	return m.Migration
+}
+
+func TestRunnerUpToLatest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, data := range map[string]string{
+		"1.0.0-create.up.sql":   "CREATE SCHEMA ${schema};",
+		"1.0.0-create.down.sql": "DROP SCHEMA ${schema};",
+		"1.1.0-users.up.sql":    "CREATE TABLE ${schema}.users ();",
+		"1.1.0-users.down.sql":  "DROP TABLE ${schema}.users;",
+	} {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tenant1 := &ConnectionMock{v: "0-null"}
+	tenant2 := &ConnectionMock{v: "1.0.0-create"}
+	broken := &FailingConnectionMock{ConnectionMock{v: "0-null"}}
+
+	loader := NewSQLMigrationLoader(dir)
+	loader.SetVariables(map[string]string{})
+
+	r := NewRunner([]Target{
+		{Name: "tenant1", Connection: tenant1, Variables: map[string]string{"schema": "tenant1"}},
+		{Name: "broken", Connection: broken, Variables: map[string]string{"schema": "broken"}},
+		{Name: "tenant2", Connection: tenant2, Variables: map[string]string{"schema": "tenant2"}},
+	}, loader)
+	r.SetParallelism(2)
+	r.SetOutput(ioutil.Discard)
+
+	report, err := r.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Results) != 3 || len(report.Failed()) != 1 || report.Failed()[0].Target != "broken" {
+		t.Fatal("unexpected report", report.Results)
+	}
+
+	if report.Err() == nil {
+		t.Error("expected aggregated error")
+	}
+
+	res := report.Results[0]
+	if res.From != "0-null" || res.To != "1.1.0-users" || len(res.Applied) != 2 {
+		t.Error("unexpected result", res)
+	}
+
+	if len(tenant1.sqls) != 2 || tenant1.sqls[0] != "CREATE SCHEMA tenant1;" || tenant1.sqls[1] != "CREATE TABLE tenant1.users ();" {
+		t.Error("unexpected sqls", tenant1.sqls)
+	}
+
+	if len(tenant2.sqls) != 1 || tenant2.sqls[0] != "CREATE TABLE tenant2.users ();" {
+		t.Error("unexpected sqls", tenant2.sqls)
+	}
+}
+
+func TestRunnerHaltOnFailure(t *testing.T) {
+	broken := &FailingConnectionMock{ConnectionMock{v: "0-null"}}
+	tenant := &ConnectionMock{v: "0-null"}
+
+	goLoader := &GoMigrationLoader{}
+	m := &SQLMigration{UpPath: "not-exists.up.sql"}
+	v, _ := VersionFromString("1-name")
+	m.SetVersion(v)
+	goLoader.Add(m)
+
+	r := NewRunner([]Target{
+		{Name: "broken", Connection: broken},
+		{Name: "tenant", Connection: tenant},
+	}, goLoader)
+	r.SetFailurePolicy(HaltOnFailure)
+	r.SetOutput(ioutil.Discard)
+
+	report, err := r.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Results[0].Err == nil || !report.Results[1].Skipped {
+		t.Error("unexpected report", report.Results)
+	}
+}
+
+func TestRunnerRejectsOpenFiles(t *testing.T) {
+	f, err := ioutil.TempFile("", "runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	goLoader := &GoMigrationLoader{}
+	m := &SQLMigration{UpFile: f}
+	v, _ := VersionFromString("1-name")
+	m.SetVersion(v)
+	goLoader.Add(m)
+
+	r := NewRunner([]Target{{Name: "tenant", Connection: &ConnectionMock{v: "0-null"}}}, goLoader)
+	r.SetOutput(ioutil.Discard)
+
+	_, err = r.UpToLatest()
+	if !errors.Is(err, ErrSharedFiles) {
+		t.Errorf("expected ErrSharedFiles, got %v", err)
+	}
+}
+
+func TestRunnerAppliesSQLInParallel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	up := filepath.Join(dir, "1-create.up.sql")
+	ioutil.WriteFile(up, []byte("CREATE SCHEMA ${schema};"), 0644)
+
+	sqlMigration := &SQLMigration{UpPath: up}
+	v, _ := VersionFromString("1-create")
+	sqlMigration.SetVersion(v)
+	goLoader := &GoMigrationLoader{}
+	goLoader.Add(&WrappedMigrationMock{sqlMigration})
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	var timedOut int32
+	tenant1 := &BarrierConnectionMock{ConnectionMock{v: "0-null"}, wg, &timedOut}
+	tenant2 := &BarrierConnectionMock{ConnectionMock{v: "0-null"}, wg, &timedOut}
+
+	r := NewRunner([]Target{
+		{Name: "tenant1", Connection: tenant1, Variables: map[string]string{"schema": "tenant1"}},
+		{Name: "tenant2", Connection: tenant2, Variables: map[string]string{"schema": "tenant2"}},
+	}, goLoader)
+	r.SetParallelism(2)
+	r.SetOutput(ioutil.Discard)
+
+	report, err := r.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Err() != nil {
+		t.Fatal(report.Err())
+	}
+
+	if atomic.LoadInt32(&timedOut) != 0 {
+		t.Error("sql migration should be applied to targets in parallel")
+	}
+
+	if len(tenant1.sqls) != 1 || tenant1.sqls[0] != "CREATE SCHEMA tenant1;" || len(tenant2.sqls) != 1 || tenant2.sqls[0] != "CREATE SCHEMA tenant2;" {
+		t.Error("wrapped sql migration should get variables of targets", tenant1.sqls, tenant2.sqls)
+	}
+}
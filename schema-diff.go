@@ -0,0 +1,498 @@
+package migo
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// ErrInspectionNotSupported is returned by Diff when connection
+// doesn't implement SchemaInspector.
+var ErrInspectionNotSupported = errors.New("connection can't inspect schema")
+
+// DriftKind is a kind of difference between schemas.
+type DriftKind string
+
+const (
+	// DriftMissing is an object that is created by migrations, but is missing in the database.
+	DriftMissing DriftKind = "missing"
+	// DriftUnexpected is an object of the database that isn't created by migrations.
+	DriftUnexpected DriftKind = "unexpected"
+	// DriftChanged is an object that differs in the database.
+	DriftChanged DriftKind = "changed"
+)
+
+// Drift is a single difference between expected and actual schemas.
+type Drift struct {
+	Kind DriftKind
+	// Object is `table`, `column`, `index` or `constraint`.
+	Object string
+	Table  string
+	// Name is the name of column, index or constraint, it is empty for tables.
+	Name string
+	// Expected and Actual are definitions of the object, one of them
+	// is empty for missing and unexpected objects.
+	Expected string
+	Actual   string
+
+	expectedTable  *Table
+	actualTable    *Table
+	expectedColumn *Column
+	actualColumn   *Column
+	expectedIndex  *Index
+	actualIndex    *Index
+}
+
+func (d Drift) String() string {
+	name := d.Table
+	if d.Name != "" {
+		name += "." + d.Name
+	}
+
+	switch d.Kind {
+	case DriftMissing:
+		return fmt.Sprintf("- %s %s: %s", d.Object, name, d.Expected)
+	case DriftUnexpected:
+		return fmt.Sprintf("+ %s %s: %s", d.Object, name, d.Actual)
+	default:
+		return fmt.Sprintf("~ %s %s: %s -> %s", d.Object, name, d.Expected, d.Actual)
+	}
+}
+
+// DriftReport lists differences between schema built by migrations
+// and schema of the database.
+type DriftReport struct {
+	Drifts []Drift
+}
+
+// HasDrift returns true when schemas differ.
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Drifts) > 0
+}
+
+// String returns a line per drift, `-` is missing in the database,
+// `+` is unexpected and `~` is changed.
+func (r *DriftReport) String() string {
+	b := &strings.Builder{}
+	for _, d := range r.Drifts {
+		fmt.Fprintln(b, d)
+	}
+
+	return b.String()
+}
+
+// DiffSchemas compares schema built by migrations with the actual one,
+// schemas aren't changed.
+func DiffSchemas(expected, actual *Schema) *DriftReport {
+	expected, actual = expected.sorted(), actual.sorted()
+
+	r := &DriftReport{}
+	for i := range expected.Tables {
+		et := &expected.Tables[i]
+		at := actual.Table(et.Name)
+		if at == nil {
+			r.Drifts = append(r.Drifts, Drift{
+				Kind:          DriftMissing,
+				Object:        "table",
+				Table:         et.Name,
+				Expected:      tableDefinition(et),
+				expectedTable: et,
+			})
+			continue
+		}
+
+		r.Drifts = append(r.Drifts, diffTables(et, at)...)
+	}
+
+	for i := range actual.Tables {
+		at := &actual.Tables[i]
+		if expected.Table(at.Name) == nil {
+			r.Drifts = append(r.Drifts, Drift{
+				Kind:        DriftUnexpected,
+				Object:      "table",
+				Table:       at.Name,
+				Actual:      tableDefinition(at),
+				actualTable: at,
+			})
+		}
+	}
+
+	return r
+}
+
+func diffTables(et, at *Table) []Drift {
+	drifts := []Drift{}
+
+	for i := range et.Columns {
+		ec := &et.Columns[i]
+		ac := findColumn(at, ec.Name)
+		switch {
+		case ac == nil:
+			drifts = append(drifts, Drift{Kind: DriftMissing, Object: "column", Table: et.Name, Name: ec.Name, Expected: ec.String(), expectedColumn: ec})
+		case ac.String() != ec.String():
+			drifts = append(drifts, Drift{Kind: DriftChanged, Object: "column", Table: et.Name, Name: ec.Name, Expected: ec.String(), Actual: ac.String(), expectedColumn: ec, actualColumn: ac})
+		}
+	}
+
+	for i := range at.Columns {
+		ac := &at.Columns[i]
+		if findColumn(et, ac.Name) == nil {
+			drifts = append(drifts, Drift{Kind: DriftUnexpected, Object: "column", Table: at.Name, Name: ac.Name, Actual: ac.String(), actualColumn: ac})
+		}
+	}
+
+	for i := range et.Indexes {
+		ei := &et.Indexes[i]
+		ai := findIndex(at, ei.Name)
+		switch {
+		case ai == nil:
+			drifts = append(drifts, Drift{Kind: DriftMissing, Object: "index", Table: et.Name, Name: ei.Name, Expected: ei.String(), expectedIndex: ei})
+		case ai.String() != ei.String():
+			drifts = append(drifts, Drift{Kind: DriftChanged, Object: "index", Table: et.Name, Name: ei.Name, Expected: ei.String(), Actual: ai.String(), expectedIndex: ei, actualIndex: ai})
+		}
+	}
+
+	for i := range at.Indexes {
+		ai := &at.Indexes[i]
+		if findIndex(et, ai.Name) == nil {
+			drifts = append(drifts, Drift{Kind: DriftUnexpected, Object: "index", Table: at.Name, Name: ai.Name, Actual: ai.String(), actualIndex: ai})
+		}
+	}
+
+	for _, ec := range et.Constraints {
+		ac := findConstraint(at, ec.key())
+		switch {
+		case ac == nil:
+			drifts = append(drifts, Drift{Kind: DriftMissing, Object: "constraint", Table: et.Name, Name: ec.key(), Expected: ec.String()})
+		case ac.String() != ec.String():
+			drifts = append(drifts, Drift{Kind: DriftChanged, Object: "constraint", Table: et.Name, Name: ec.key(), Expected: ec.String(), Actual: ac.String()})
+		}
+	}
+
+	for _, ac := range at.Constraints {
+		if findConstraint(et, ac.key()) == nil {
+			drifts = append(drifts, Drift{Kind: DriftUnexpected, Object: "constraint", Table: at.Name, Name: ac.key(), Actual: ac.String()})
+		}
+	}
+
+	return drifts
+}
+
+func findColumn(t *Table, name string) *Column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+
+	return nil
+}
+
+func findIndex(t *Table, name string) *Index {
+	for i := range t.Indexes {
+		if t.Indexes[i].Name == name {
+			return &t.Indexes[i]
+		}
+	}
+
+	return nil
+}
+
+func findConstraint(t *Table, key string) *Constraint {
+	for i := range t.Constraints {
+		if t.Constraints[i].key() == key {
+			return &t.Constraints[i]
+		}
+	}
+
+	return nil
+}
+
+func tableDefinition(t *Table) string {
+	columns := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		columns[i] = c.String()
+	}
+
+	return "(" + strings.Join(columns, ", ") + ")"
+}
+
+// Skeleton returns statements of a corrective migration that brings schema
+// built by migrations to the actual one, so changes made by hand become
+// part of migrations. Changes that can't be expressed generally are left
+// as TODO comments (see Unresolved), statements have to be reviewed before use.
+// Down statements revert up statements in the reverse order.
+func (r *DriftReport) Skeleton() (up, down string) {
+	upB := &strings.Builder{}
+	downs := make([]string, 0, len(r.Drifts))
+	for _, d := range r.Drifts {
+		u, dn, ok := d.statements()
+		if !ok {
+			u = fmt.Sprintf("-- TODO: %s\n", d)
+			dn = fmt.Sprintf("-- TODO: revert %s\n", d)
+		}
+		upB.WriteString(u)
+		downs = append(downs, dn)
+	}
+
+	for i := len(downs) - 1; i >= 0; i-- {
+		down += downs[i]
+	}
+
+	return upB.String(), down
+}
+
+// Unresolved returns drifts that Skeleton leaves as TODO comments,
+// e.g. changed columns and constraints of existing tables.
+func (r *DriftReport) Unresolved() []Drift {
+	drifts := []Drift{}
+	for _, d := range r.Drifts {
+		if _, _, ok := d.statements(); !ok {
+			drifts = append(drifts, d)
+		}
+	}
+
+	return drifts
+}
+
+// statements returns up and down statements of the drift,
+// ok is false when the drift can't be expressed generally.
+func (d Drift) statements() (up, down string, ok bool) {
+	switch {
+	case d.Object == "table" && d.Kind == DriftUnexpected:
+		return createTable(d.actualTable, true), fmt.Sprintf("DROP TABLE %s;\n", d.Table), true
+	case d.Object == "table" && d.Kind == DriftMissing:
+		return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", d.Table), createTable(d.expectedTable, false), true
+	case d.Object == "column" && d.Kind == DriftUnexpected:
+		return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", d.Table, d.Actual), fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", d.Table, d.Name), true
+	case d.Object == "column" && d.Kind == DriftMissing:
+		return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", d.Table, d.Name), fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", d.Table, d.Expected), true
+	case d.Object == "index" && d.Kind == DriftUnexpected:
+		return createIndex(d.Table, d.actualIndex) + ";\n", fmt.Sprintf("DROP INDEX %s;\n", d.Name), true
+	case d.Object == "index" && d.Kind == DriftMissing:
+		return fmt.Sprintf("DROP INDEX %s;\n", d.Name), createIndex(d.Table, d.expectedIndex) + ";\n", true
+	}
+
+	return "", "", false
+}
+
+// createTable returns statements that create the table with its constraints
+// and indexes. Indexes made by constraints (named as constraints or sqlite
+// autoindexes) aren't created separately, unique autoindexes of sqlite
+// become UNIQUE constraints.
+func createTable(t *Table, ifNotExists bool) string {
+	defs := []string{}
+	for _, c := range t.Columns {
+		defs = append(defs, c.String())
+	}
+
+	byConstraint := map[string]bool{}
+	pk := ""
+	for _, c := range t.Constraints {
+		def := c.Type + " (" + strings.Join(c.Columns, ", ") + ")"
+		if c.References != "" {
+			def += " REFERENCES " + c.References
+		}
+
+		// databases name primary keys themselves, e.g. mysql doesn't accept
+		// its own `PRIMARY` name
+		if c.Name != "" && c.Type != "PRIMARY KEY" {
+			def = "CONSTRAINT " + c.Name + " " + def
+		}
+		defs = append(defs, def)
+
+		byConstraint[c.Name] = c.Name != ""
+		if c.Type == "PRIMARY KEY" {
+			pk = strings.Join(c.Columns, ", ")
+		}
+	}
+
+	indexes := &strings.Builder{}
+	for i := range t.Indexes {
+		idx := &t.Indexes[i]
+		columns := strings.Join(idx.Columns, ", ")
+		switch {
+		case byConstraint[idx.Name]:
+		case strings.HasPrefix(idx.Name, "sqlite_autoindex_"):
+			if columns != pk {
+				defs = append(defs, "UNIQUE ("+columns+")")
+			}
+		default:
+			fmt.Fprintf(indexes, "%s;\n", createIndex(t.Name, idx))
+		}
+	}
+
+	create := "CREATE TABLE "
+	if ifNotExists {
+		create += "IF NOT EXISTS "
+	}
+
+	return fmt.Sprintf("%s%s (%s);\n", create, t.Name, strings.Join(defs, ", ")) + indexes.String()
+}
+
+func createIndex(table string, i *Index) string {
+	unique := ""
+	if i.Unique {
+		unique = "UNIQUE "
+	}
+
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, i.Name, table, strings.Join(i.Columns, ", "))
+}
+
+// Diff applies migrations that are applied to the database of migrate
+// to an empty scratch database and compares their schemas.
+// Both connections must implement SchemaInspector.
+func (m *Migrate) Diff(scratch Connection) (*DriftReport, error) {
+	live, ok := m.c.(SchemaInspector)
+	if !ok {
+		return nil, ErrInspectionNotSupported
+	}
+
+	scratchInspector, ok := scratch.(SchemaInspector)
+	if !ok {
+		return nil, ErrInspectionNotSupported
+	}
+
+	err := m.loadMigrations()
+	if err != nil {
+		return nil, errors.New("can't load migrations " + err.Error())
+	}
+
+	verStrs, err := m.c.LoadVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[string]bool{}
+	for _, v := range verStrs {
+		applied[v] = true
+	}
+
+	migrations := []Migration{}
+	for _, migration := range m.migrations {
+		if applied[migration.Version().String()] {
+			migrations = append(migrations, migration)
+		}
+	}
+
+	err = replay(scratch, migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := scratchInspector.InspectSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := live.InspectSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffSchemas(expected, actual), nil
+}
+
+// DiffSchemaFile compares schema built by all migrations with the desired
+// schema created by sql script at `path`. Migrations are replayed on `scratch`,
+// the script is executed on `desired`, both must be empty databases
+// that implement SchemaInspector. Skeleton of the report brings
+// schema of migrations to the desired one.
+func (m *Migrate) DiffSchemaFile(path string, scratch, desired Connection) (*DriftReport, error) {
+	scratchInspector, ok := scratch.(SchemaInspector)
+	if !ok {
+		return nil, ErrInspectionNotSupported
+	}
+
+	desiredInspector, ok := desired.(SchemaInspector)
+	if !ok {
+		return nil, ErrInspectionNotSupported
+	}
+
+	desiredSchema, err := desiredInspector.InspectSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := hasVersions(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	if applied || len(desiredSchema.Tables) > 0 {
+		return nil, errors.New("desired database must be empty")
+	}
+
+	err = m.loadMigrations()
+	if err != nil {
+		return nil, errors.New("can't load migrations " + err.Error())
+	}
+
+	err = replay(scratch, m.migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	script := &SQLMigration{UpPath: path}
+	script.SetConnection(desired)
+	err = script.Up()
+	if err != nil {
+		return nil, fmt.Errorf("can't apply '%s': %w", path, err)
+	}
+
+	expected, err := scratchInspector.InspectSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	desiredSchema, err = desiredInspector.InspectSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffSchemas(expected, desiredSchema), nil
+}
+
+// replay applies migrations to an empty scratch database.
+func replay(scratch Connection, migrations []Migration) error {
+	applied, err := hasVersions(scratch)
+	if err != nil {
+		return err
+	}
+
+	if applied {
+		return errors.New("scratch database must be empty")
+	}
+
+	s := NewMigrate(scratch)
+	s.SetOutput(ioutil.Discard)
+	s.SetOutOfOrder(OutOfOrderApply)
+	s.migrationsLoaded = true
+	for _, migration := range migrations {
+		s.Add(migration)
+	}
+
+	err = s.UpToLatest()
+	if err != nil {
+		return fmt.Errorf("can't replay migrations: %w", err)
+	}
+
+	return nil
+}
+
+// hasVersions returns true when any migration is applied to the database.
+func hasVersions(c Connection) (bool, error) {
+	vers, err := c.LoadVersions()
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range vers {
+		if ver, err := VersionFromString(v); err != nil || ver.StringWithoutName() != "0" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
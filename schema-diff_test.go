@@ -0,0 +1,242 @@
+package migo
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+// InspectorConnectionMock describes tables created by executed
+// `CREATE TABLE <name> ...` statements.
+type InspectorConnectionMock struct {
+	HistoryConnectionMock
+}
+
+func (c *InspectorConnectionMock) InspectSchema() (*Schema, error) {
+	s := &Schema{}
+	for _, sql := range c.sqls {
+		fields := strings.Fields(sql)
+		if len(fields) > 2 && fields[0] == "CREATE" && fields[1] == "TABLE" {
+			s.Tables = append(s.Tables, Table{Name: fields[2], Columns: []Column{{Name: "id", Type: "INT"}}})
+		}
+	}
+
+	return s, nil
+}
+
+func TestDiffSchemas(t *testing.T) {
+	expected := &Schema{Tables: []Table{
+		{
+			Name: "users",
+			Columns: []Column{
+				{Name: "id", Type: "INTEGER"},
+				{Name: "email", Type: "TEXT", Nullable: true},
+			},
+			Indexes: []Index{{Name: "users_email", Columns: []string{"email"}, Unique: true}},
+		},
+		{Name: "orders", Columns: []Column{{Name: "id", Type: "INTEGER"}}},
+	}}
+
+	actual := &Schema{Tables: []Table{
+		{
+			Name: "users",
+			Columns: []Column{
+				{Name: "id", Type: "INTEGER"},
+				{Name: "email", Type: "VARCHAR(255)", Nullable: true},
+				{Name: "phone", Type: "TEXT", Nullable: true},
+			},
+			Indexes: []Index{{Name: "users_phone", Columns: []string{"phone"}}},
+		},
+		{Name: "audit", Columns: []Column{{Name: "id", Type: "INTEGER"}}},
+	}}
+
+	report := DiffSchemas(expected, actual)
+
+	_ = actual.String()
+	if expected.Tables[0].Name != "users" || actual.Tables[1].Name != "audit" {
+		t.Error("schemas should not be sorted in place")
+	}
+
+	expectedReport := []string{
+		"- table orders: (id INTEGER NOT NULL)",
+		"~ column users.email: email TEXT -> email VARCHAR(255)",
+		"+ column users.phone: phone TEXT",
+		"- index users.users_email: users_email (email) UNIQUE",
+		"+ index users.users_phone: users_phone (phone)",
+		"+ table audit: (id INTEGER NOT NULL)",
+	}
+	if report.String() != strings.Join(expectedReport, "\n")+"\n" {
+		t.Errorf("unexpected report\n%s", report)
+	}
+
+	up, down := report.Skeleton()
+	for _, s := range []string{
+		"DROP TABLE IF EXISTS orders;",
+		"-- TODO: ~ column users.email",
+		"ALTER TABLE users ADD COLUMN phone TEXT;",
+		"DROP INDEX users_email;",
+		"CREATE INDEX users_phone ON users (phone);",
+		"CREATE TABLE IF NOT EXISTS audit (id INTEGER NOT NULL);",
+	} {
+		if !strings.Contains(up, s) {
+			t.Errorf("up skeleton doesn't contain '%s'\n%s", s, up)
+		}
+	}
+
+	for _, s := range []string{
+		"CREATE TABLE orders (id INTEGER NOT NULL);",
+		"ALTER TABLE users DROP COLUMN phone;",
+		"CREATE UNIQUE INDEX users_email ON users (email);",
+		"DROP TABLE audit;",
+	} {
+		if !strings.Contains(down, s) {
+			t.Errorf("down skeleton doesn't contain '%s'\n%s", s, down)
+		}
+	}
+
+	if len(report.Unresolved()) != 1 || report.Unresolved()[0].Name != "email" {
+		t.Errorf("unexpected unresolved drifts %v", report.Unresolved())
+	}
+
+	if DiffSchemas(expected, expected).HasDrift() {
+		t.Error("same schemas should not drift")
+	}
+}
+
+func TestSkeletonCreatesKeysAndIndexes(t *testing.T) {
+	orders := Table{
+		Name: "orders",
+		Columns: []Column{
+			{Name: "id", Type: "INTEGER"},
+			{Name: "user_id", Type: "INTEGER"},
+			{Name: "number", Type: "TEXT"},
+		},
+		Indexes: []Index{
+			{Name: "orders_pkey", Columns: []string{"id"}, Unique: true},
+			{Name: "orders_user", Columns: []string{"user_id"}},
+			{Name: "sqlite_autoindex_orders_1", Columns: []string{"number"}, Unique: true},
+		},
+		Constraints: []Constraint{
+			{Name: "orders_pkey", Type: "PRIMARY KEY", Columns: []string{"id"}},
+			{Name: "orders_user_fk", Type: "FOREIGN KEY", Columns: []string{"user_id"}, References: "users(id)"},
+		},
+	}
+
+	up, down := DiffSchemas(&Schema{}, &Schema{Tables: []Table{orders}}).Skeleton()
+	expected := "CREATE TABLE IF NOT EXISTS orders (id INTEGER NOT NULL, user_id INTEGER NOT NULL, number TEXT NOT NULL, " +
+		"PRIMARY KEY (id), CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users(id), UNIQUE (number));\n" +
+		"CREATE INDEX orders_user ON orders (user_id);\n"
+	if up != expected || down != "DROP TABLE orders;\n" {
+		t.Errorf("unexpected skeleton\n%s\n%s", up, down)
+	}
+
+	report := DiffSchemas(&Schema{Tables: []Table{orders}}, &Schema{})
+	up, down = report.Skeleton()
+	if up != "DROP TABLE IF EXISTS orders;\n" || down != strings.Replace(expected, "IF NOT EXISTS ", "", 1) {
+		t.Errorf("unexpected skeleton\n%s\n%s", up, down)
+	}
+
+	if len(report.Unresolved()) != 0 {
+		t.Errorf("unexpected unresolved drifts %v", report.Unresolved())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, up := range map[string]string{
+		"1-users.sql":  "CREATE TABLE users (id INT);",
+		"2-orders.sql": "CREATE TABLE orders (id INT);",
+		"3-items.sql":  "CREATE TABLE items (id INT);",
+	} {
+		ioutil.WriteFile(path.Join(dir, name), []byte("-- migo:up\n"+up+"\n-- migo:down\nSELECT 1;\n"), 0644)
+	}
+
+	live := &InspectorConnectionMock{}
+	live.versions = []string{"1-users", "2-orders"}
+	live.sqls = []string{"CREATE TABLE users (id INT);", "CREATE TABLE audit (id INT);"}
+
+	scratch := &InspectorConnectionMock{}
+	m := NewMigrate(live, NewSQLMigrationLoader(dir))
+	m.SetOutput(ioutil.Discard)
+
+	report, err := m.Diff(scratch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scratch.sqls) != 2 {
+		t.Errorf("only applied migrations should be replayed, got %q", scratch.sqls)
+	}
+
+	expected := "- table orders: (id INT NOT NULL)\n+ table audit: (id INT NOT NULL)\n"
+	if report.String() != expected {
+		t.Errorf("unexpected report\n%s", report)
+	}
+
+	m = NewMigrate(live, NewSQLMigrationLoader(dir))
+	m.SetOutput(ioutil.Discard)
+	_, err = m.Diff(scratch)
+	if err == nil || !strings.Contains(err.Error(), "scratch database must be empty") {
+		t.Errorf("used scratch database should fail, got %v", err)
+	}
+
+	_, err = NewMigrate(&ConnectionMock{}).Diff(scratch)
+	if err != ErrInspectionNotSupported {
+		t.Errorf("expected ErrInspectionNotSupported, got %v", err)
+	}
+}
+
+func TestDiffSchemaFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, up := range map[string]string{
+		"1-users.sql":  "CREATE TABLE users (id INT);",
+		"2-orders.sql": "CREATE TABLE orders (id INT);",
+	} {
+		ioutil.WriteFile(path.Join(dir, name), []byte("-- migo:up\n"+up+"\n-- migo:down\nSELECT 1;\n"), 0644)
+	}
+
+	schema := path.Join(dir, "schema.sql")
+	ioutil.WriteFile(schema, []byte("CREATE TABLE users (id INT);\nCREATE TABLE audit (id INT);\n"), 0644)
+
+	scratch, desired := &InspectorConnectionMock{}, &InspectorConnectionMock{}
+	m := NewMigrate(nil, NewSQLMigrationLoader(dir))
+	m.SetOutput(ioutil.Discard)
+
+	report, err := m.DiffSchemaFile(schema, scratch, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scratch.sqls) != 2 || len(desired.sqls) != 2 {
+		t.Errorf("unexpected statements %q %q", scratch.sqls, desired.sqls)
+	}
+
+	expected := "- table orders: (id INT NOT NULL)\n+ table audit: (id INT NOT NULL)\n"
+	if report.String() != expected {
+		t.Errorf("unexpected report\n%s", report)
+	}
+
+	applied := &InspectorConnectionMock{}
+	applied.versions = []string{"1-users"}
+	_, err = m.DiffSchemaFile(schema, &InspectorConnectionMock{}, applied)
+	if err == nil || !strings.Contains(err.Error(), "desired database must be empty") {
+		t.Errorf("desired database with applied migrations should fail, got %v", err)
+	}
+
+	_, err = m.DiffSchemaFile(schema, &InspectorConnectionMock{}, &ConnectionMock{})
+	if err != ErrInspectionNotSupported {
+		t.Errorf("expected ErrInspectionNotSupported, got %v", err)
+	}
+}
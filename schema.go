@@ -0,0 +1,159 @@
+package migo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema describes structure of a database, it is used
+// to compare databases, e.g. by migotest and Diff.
+type Schema struct {
+	Tables []Table
+}
+
+// Table is a table of Schema, columns are in the order of the table.
+type Table struct {
+	Name        string
+	Columns     []Column
+	Indexes     []Index
+	Constraints []Constraint
+}
+
+// Column is a column of Table.
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  string
+}
+
+// Index is an index of Table.
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// Constraint is a primary key, foreign key or unique constraint of Table.
+// Name is empty when database doesn't name constraints (e.g. sqlite).
+type Constraint struct {
+	Name    string
+	Type    string
+	Columns []string
+	// References is `table(columns)` of foreign key.
+	References string
+}
+
+// SchemaInspector is implemented by connections that can describe
+// schema of their database. Version and history tables are not included.
+type SchemaInspector interface {
+	InspectSchema() (*Schema, error)
+}
+
+// Table returns table with the name or nil.
+func (s *Schema) Table(name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+
+	return nil
+}
+
+// Sort sorts tables, indexes and constraints, order of columns is kept.
+func (s *Schema) Sort() {
+	sort.Slice(s.Tables, func(i, j int) bool {
+		return s.Tables[i].Name < s.Tables[j].Name
+	})
+
+	for _, t := range s.Tables {
+		sort.Slice(t.Indexes, func(i, j int) bool {
+			return t.Indexes[i].Name < t.Indexes[j].Name
+		})
+
+		sort.Slice(t.Constraints, func(i, j int) bool {
+			return t.Constraints[i].key() < t.Constraints[j].key()
+		})
+	}
+}
+
+// sorted returns sorted copy of the schema, the schema isn't changed.
+func (s *Schema) sorted() *Schema {
+	c := &Schema{Tables: make([]Table, len(s.Tables))}
+	for i, t := range s.Tables {
+		t.Indexes = append([]Index(nil), t.Indexes...)
+		t.Constraints = append([]Constraint(nil), t.Constraints...)
+		c.Tables[i] = t
+	}
+	c.Sort()
+
+	return c
+}
+
+// String returns text representation of the schema, a line per table,
+// column and index. Equal schemas have equal representations.
+func (s *Schema) String() string {
+	b := &strings.Builder{}
+	for _, t := range s.sorted().Tables {
+		fmt.Fprintf(b, "table %s\n", t.Name)
+		for _, c := range t.Columns {
+			fmt.Fprintf(b, "  column %s.%s\n", t.Name, c)
+		}
+
+		for _, i := range t.Indexes {
+			fmt.Fprintf(b, "  index %s.%s\n", t.Name, i)
+		}
+
+		for _, c := range t.Constraints {
+			fmt.Fprintf(b, "  constraint %s.%s\n", t.Name, c)
+		}
+	}
+
+	return b.String()
+}
+
+func (c Column) String() string {
+	s := c.Name + " " + c.Type
+	if !c.Nullable {
+		s += " NOT NULL"
+	}
+
+	if c.Default != "" {
+		s += " DEFAULT " + c.Default
+	}
+
+	return s
+}
+
+func (i Index) String() string {
+	s := i.Name + " (" + strings.Join(i.Columns, ", ") + ")"
+	if i.Unique {
+		s += " UNIQUE"
+	}
+
+	return s
+}
+
+func (c Constraint) String() string {
+	s := c.Type + " (" + strings.Join(c.Columns, ", ") + ")"
+	if c.References != "" {
+		s += " REFERENCES " + c.References
+	}
+
+	if c.Name != "" {
+		s = c.Name + " " + s
+	}
+
+	return s
+}
+
+// key identifies constraint by name or, when it is not named, by its definition.
+func (c Constraint) key() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return c.Type + " (" + strings.Join(c.Columns, ", ") + ")"
+}
@@ -0,0 +1,202 @@
+package migo
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/walkline/migo/sqlscanner"
+)
+
+// SeedsDir is the directory of sql seeds inside of the directory of migrations.
+// Files of `seeds` run for every environment, files of `seeds/<env>` run only for the environment.
+const SeedsDir = "seeds"
+
+// ErrSeedsNotSupported is returned by Seed when connection doesn't implement SeedConnection.
+var ErrSeedsNotSupported = errors.New("connection can't track seeds")
+
+// Seed loads data, e.g. reference or demo data. Seeds are not versioned,
+// every seed runs once per environment.
+type Seed interface {
+	// Name identifies seed in the seed table.
+	Name() string
+	SetConnection(c Connection)
+	Run() error
+}
+
+// SeedLoader is implemented by loaders that load seeds next to migrations.
+type SeedLoader interface {
+	// LoadSeeds returns seeds of the environment in the order they run.
+	LoadSeeds(env string) ([]Seed, error)
+}
+
+// SeedConnection is implemented by connections that track applied seeds
+// in their own table, separately from versions and history.
+type SeedConnection interface {
+	// AppliedSeeds returns names of seeds applied in the environment.
+	AppliedSeeds(env string) ([]string, error)
+	RecordSeed(env, name string, appliedAt time.Time) error
+}
+
+// SQLSeed is a sql file of `seeds` directory.
+type SQLSeed struct {
+	c         Connection
+	name      string
+	Path      string
+	Dialect   sqlscanner.Dialect
+	Variables map[string]string
+}
+
+func (s *SQLSeed) Name() string {
+	return s.name
+}
+
+func (s *SQLSeed) SetConnection(c Connection) {
+	s.c = c
+}
+
+// Run executes statements of the file in a transaction.
+func (s *SQLSeed) Run() error {
+	script := &SQLMigration{
+		UpPath:    s.Path,
+		Dialect:   s.Dialect,
+		Variables: s.Variables,
+	}
+	script.SetConnection(s.c)
+
+	return script.Up()
+}
+
+// LoadSeeds loads sql files of `seeds` directory and then files
+// of `seeds/<env>`, files are ordered by name.
+func (l *SQLMigrationsLoader) LoadSeeds(env string) ([]Seed, error) {
+	seeds := []Seed{}
+	for _, dir := range []string{SeedsDir, filepath.Join(SeedsDir, env)} {
+		if env == "" && dir != SeedsDir {
+			continue
+		}
+
+		entries, err := ioutil.ReadDir(filepath.Join(l.path, dir))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+				continue
+			}
+
+			seeds = append(seeds, &SQLSeed{
+				name:      filepath.ToSlash(filepath.Join(dir, entry.Name())),
+				Path:      filepath.Join(l.path, dir, entry.Name()),
+				Dialect:   l.dialect,
+				Variables: l.variables,
+			})
+		}
+	}
+
+	return seeds, nil
+}
+
+type goSeed struct {
+	env  string
+	seed Seed
+}
+
+// AddSeed adds go seed of the environment, seed with empty
+// `env` runs for every environment.
+func (l *GoMigrationLoader) AddSeed(env string, s Seed) {
+	l.seeds = append(l.seeds, goSeed{env: env, seed: s})
+}
+
+// LoadSeeds returns go seeds of the environment in the order they were added.
+func (l *GoMigrationLoader) LoadSeeds(env string) ([]Seed, error) {
+	seeds := []Seed{}
+	for _, s := range l.seeds {
+		if s.env == "" || s.env == env {
+			seeds = append(seeds, s.seed)
+		}
+	}
+
+	return seeds, nil
+}
+
+// Seed runs seeds of the environment that are not applied yet, sql seeds
+// and go seeds are loaded from loaders that implement SeedLoader.
+// Seeds are tracked by connection that implements SeedConnection,
+// they don't change versions and history of migrations.
+func (m *Migrate) Seed(env string) error {
+	sc, ok := m.c.(SeedConnection)
+	if !ok {
+		return ErrSeedsNotSupported
+	}
+
+	seeds := []Seed{}
+	names := map[string]bool{}
+	for _, loader := range m.loaders {
+		sl, ok := loader.(SeedLoader)
+		if !ok {
+			continue
+		}
+
+		loaded, err := sl.LoadSeeds(env)
+		if err != nil {
+			return fmt.Errorf("can't load seeds: %w", err)
+		}
+
+		for _, s := range loaded {
+			if names[s.Name()] {
+				return fmt.Errorf("seed '%s' is loaded twice", s.Name())
+			}
+			names[s.Name()] = true
+		}
+
+		seeds = append(seeds, loaded...)
+	}
+
+	applied, err := sc.AppliedSeeds(env)
+	if err != nil {
+		return err
+	}
+	sort.Strings(applied)
+
+	pending := []Seed{}
+	for _, s := range seeds {
+		i := sort.SearchStrings(applied, s.Name())
+		if i < len(applied) && applied[i] == s.Name() {
+			continue
+		}
+
+		pending = append(pending, s)
+	}
+
+	fmt.Fprintf(m.output(), "Going to run %d seed(s) of '%s'...\n", len(pending), env)
+
+	for _, s := range pending {
+		fmt.Fprintf(m.output(), "Seeding '%s'... \n", s.Name())
+		start := time.Now()
+
+		s.SetConnection(m.c)
+		err := s.Run()
+		if err != nil {
+			return fmt.Errorf("seed '%s': %w", s.Name(), err)
+		}
+
+		err = sc.RecordSeed(env, s.Name(), start)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(m.output(), "Seeded '%s'! Duration: %v.\n\n", s.Name(), time.Since(start))
+	}
+
+	return nil
+}
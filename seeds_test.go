@@ -0,0 +1,130 @@
+package migo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+type SeedConnectionMock struct {
+	ConnectionMock
+	seeds map[string][]string
+}
+
+func (c *SeedConnectionMock) AppliedSeeds(env string) ([]string, error) {
+	return c.seeds[env], nil
+}
+
+func (c *SeedConnectionMock) RecordSeed(env, name string, appliedAt time.Time) error {
+	if c.seeds == nil {
+		c.seeds = map[string][]string{}
+	}
+	c.seeds[env] = append(c.seeds[env], name)
+
+	return nil
+}
+
+type seedMock struct {
+	c    Connection
+	name string
+}
+
+func (s *seedMock) Name() string {
+	return s.name
+}
+
+func (s *seedMock) SetConnection(c Connection) {
+	s.c = c
+}
+
+func (s *seedMock) Run() error {
+	return s.c.Exec("SEED " + s.name)
+}
+
+func TestSeed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seeds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(path.Join(dir, SeedsDir, "dev"), os.ModePerm)
+	os.MkdirAll(path.Join(dir, SeedsDir, "prod"), os.ModePerm)
+	ioutil.WriteFile(path.Join(dir, "1-users.up.sql"), []byte("CREATE TABLE users (id INT);"), 0644)
+	ioutil.WriteFile(path.Join(dir, "1-users.down.sql"), []byte("DROP TABLE users;"), 0644)
+	ioutil.WriteFile(path.Join(dir, SeedsDir, "roles.sql"), []byte("INSERT INTO roles VALUES (1);"), 0644)
+	ioutil.WriteFile(path.Join(dir, SeedsDir, "dev", "1-users.sql"), []byte("INSERT INTO users VALUES (1);"), 0644)
+	ioutil.WriteFile(path.Join(dir, SeedsDir, "prod", "1-users.sql"), []byte("INSERT INTO users VALUES (2);"), 0644)
+
+	sqlLoader := NewSQLMigrationLoader(dir)
+	migrations, err := sqlLoader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 1 {
+		t.Fatalf("seeds should not be loaded as migrations, got %d migrations", len(migrations))
+	}
+
+	goLoader := &GoMigrationLoader{}
+	goLoader.AddSeed("dev", &seedMock{name: "demo-orders"})
+	goLoader.AddSeed("", &seedMock{name: "settings"})
+
+	c := &SeedConnectionMock{}
+	m := NewMigrate(c, sqlLoader, goLoader)
+	m.SetOutput(ioutil.Discard)
+
+	err = m.Seed("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"INSERT INTO roles VALUES (1);",
+		"INSERT INTO users VALUES (1);",
+		"SEED demo-orders",
+		"SEED settings",
+	}
+	if strings.Join(c.sqls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected statements %q", c.sqls)
+	}
+
+	if c.v != "" {
+		t.Errorf("seeds should not change version, got '%s'", c.v)
+	}
+
+	c.sqls = nil
+	err = m.Seed("dev")
+	if err != nil || len(c.sqls) != 0 {
+		t.Errorf("applied seeds should not run again: %v %q", err, c.sqls)
+	}
+
+	err = m.Seed("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []string{
+		"INSERT INTO roles VALUES (1);",
+		"INSERT INTO users VALUES (2);",
+		"SEED settings",
+	}
+	if strings.Join(c.sqls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected statements %q", c.sqls)
+	}
+
+	if strings.Join(c.seeds["prod"], ",") != "seeds/roles.sql,seeds/prod/1-users.sql,settings" {
+		t.Errorf("unexpected applied seeds %q", c.seeds["prod"])
+	}
+}
+
+func TestSeedNotSupported(t *testing.T) {
+	m := NewMigrate(&ConnectionMock{})
+	err := m.Seed("dev")
+	if !errors.Is(err, ErrSeedsNotSupported) {
+		t.Errorf("expected ErrSeedsNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package migo
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultSetName is the name of the default migration set,
+// it uses DefaultGoMigrationLoader and the default version table.
+const DefaultSetName = ""
+
+// ErrSetsNotSupported is returned for named sets when connection
+// doesn't implement SetAwareConnection, versions of different sets
+// would be mixed in a single version table.
+var ErrSetsNotSupported = errors.New("connection can't keep versions of migration sets separately")
+
+// SetAwareConnection is implemented by connections that can keep
+// versions of different migration sets in different tables.
+type SetAwareConnection interface {
+	// ForSet returns connection that stores versions of the set separately.
+	ForSet(name string) Connection
+}
+
+// MigrationSet is a named group of migrations, e.g. migrations
+// of a single database when project owns many of them.
+// Migrations of different sets never see each other.
+type MigrationSet struct {
+	Name string
+
+	mu         sync.Mutex
+	goLoader   *GoMigrationLoader
+	sqlLoaders []*SQLMigrationsLoader
+}
+
+var (
+	setsMu sync.Mutex
+	sets   = map[string]*MigrationSet{
+		DefaultSetName: {
+			Name:     DefaultSetName,
+			goLoader: DefaultGoMigrationLoader,
+		},
+	}
+)
+
+// Set returns migration set with the given name, set is created on the first call.
+// Go migrations of the set are registered with `migo.Set("billing").Add(...)`.
+func Set(name string) *MigrationSet {
+	setsMu.Lock()
+	defer setsMu.Unlock()
+
+	s, found := sets[name]
+	if !found {
+		s = &MigrationSet{
+			Name:     name,
+			goLoader: &GoMigrationLoader{},
+		}
+		sets[name] = s
+	}
+
+	return s
+}
+
+// Add adds go migration to the set.
+func (s *MigrationSet) Add(m Migration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.goLoader.Add(m)
+}
+
+// AddSeed adds go seed of the environment to the set,
+// seed with empty `env` runs for every environment.
+func (s *MigrationSet) AddSeed(env string, seed Seed) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.goLoader.AddSeed(env, seed)
+}
+
+// AddSQLDir adds directory with sql migrations to the set.
+// Returned loader can be used to set dialect or variables.
+func (s *MigrationSet) AddSQLDir(path string) *SQLMigrationsLoader {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := NewSQLMigrationLoader(path)
+	s.sqlLoaders = append(s.sqlLoaders, l)
+
+	return l
+}
+
+// Loaders returns loaders of all migrations of the set.
+func (s *MigrationSet) Loaders() []MigrationLoader {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loaders := []MigrationLoader{}
+	for _, l := range s.sqlLoaders {
+		loaders = append(loaders, l)
+	}
+
+	return append(loaders, s.goLoader)
+}
+
+// Connection returns connection that keeps versions of the set,
+// it is `c` itself for the default set. Returns ErrSetsNotSupported
+// for other sets when `c` doesn't implement SetAwareConnection.
+func (s *MigrationSet) Connection(c Connection) (Connection, error) {
+	if s.Name == DefaultSetName {
+		return c, nil
+	}
+
+	if sc, ok := c.(SetAwareConnection); ok {
+		return sc.ForSet(s.Name), nil
+	}
+
+	return nil, fmt.Errorf("%w: set '%s'", ErrSetsNotSupported, s.Name)
+}
+
+// NewMigrate creates Migrate that applies only migrations of the set.
+func (s *MigrationSet) NewMigrate(c Connection) (*Migrate, error) {
+	sc, err := s.Connection(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMigrate(sc, s.Loaders()...), nil
+}
+
+// Clear removes all migrations from the set.
+func (s *MigrationSet) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.goLoader.Clear()
+	s.sqlLoaders = nil
+}
@@ -0,0 +1,95 @@
+package migo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type SetConnectionMock struct {
+	ConnectionMock
+	sets map[string]*ConnectionMock
+}
+
+func (c *SetConnectionMock) ForSet(name string) Connection {
+	if c.sets == nil {
+		c.sets = map[string]*ConnectionMock{}
+	}
+
+	if _, found := c.sets[name]; !found {
+		c.sets[name] = &ConnectionMock{v: "0-null"}
+	}
+
+	return c.sets[name]
+}
+
+func TestMigrationSets(t *testing.T) {
+	defer Set("billing").Clear()
+	defer Set("auth").Clear()
+
+	billingMigration := &SQLMigration{UpPath: "billing.up.sql"}
+	v, _ := VersionFromString("1-billing")
+	billingMigration.SetVersion(v)
+	Set("billing").Add(billingMigration)
+
+	authMigration := &SQLMigration{UpPath: "auth.up.sql"}
+	v, _ = VersionFromString("1-auth")
+	authMigration.SetVersion(v)
+	Set("auth").Add(authMigration)
+
+	if Set("billing") != Set("billing") {
+		t.Error("set should be created once")
+	}
+
+	for name, expected := range map[string]string{"billing": "1-billing", "auth": "1-auth"} {
+		loaders := Set(name).Loaders()
+		migrations := []Migration{}
+		for _, l := range loaders {
+			ms, _ := l.Load()
+			migrations = append(migrations, ms...)
+		}
+
+		if len(migrations) != 1 || migrations[0].Version().String() != expected {
+			t.Error("unexpected migrations of set", name, migrations)
+		}
+	}
+
+	c := &SetConnectionMock{ConnectionMock: ConnectionMock{v: "0-null"}}
+	ioutil.WriteFile("billing.up.sql", []byte("CREATE TABLE invoices ();"), 0644)
+	defer removeFiles("billing.up.sql")
+
+	m, err := Set("billing").NewMigrate(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetOutput(ioutil.Discard)
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.v != "0-null" || c.sets["billing"].v != "1-billing" || len(c.sets["billing"].sqls) != 1 {
+		t.Error("versions of the set should be stored separately")
+	}
+
+	if sc, err := Set(DefaultSetName).Connection(c); sc != c || err != nil {
+		t.Error("default set should use connection as is")
+	}
+
+	plain := &ConnectionMock{v: "0-null"}
+	if _, err := Set(DefaultSetName).Connection(plain); err != nil {
+		t.Error("default set should not need SetAwareConnection", err)
+	}
+
+	_, err = Set("billing").NewMigrate(plain)
+	if !errors.Is(err, ErrSetsNotSupported) {
+		t.Errorf("expected ErrSetsNotSupported, got %v", err)
+	}
+}
+
+func removeFiles(names ...string) {
+	for _, name := range names {
+		os.Remove(name)
+	}
+}
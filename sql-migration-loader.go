@@ -1,9 +1,12 @@
 package migo
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/walkline/migo/sqlscanner"
 )
 
 type MigrationLoader interface {
@@ -11,7 +14,10 @@ type MigrationLoader interface {
 }
 
 type SQLMigrationsLoader struct {
-	path string
+	path             string
+	dialect          sqlscanner.Dialect
+	variables        map[string]string
+	allowMissingDown bool
 }
 
 func NewSQLMigrationLoader(path string) *SQLMigrationsLoader {
@@ -20,50 +26,263 @@ func NewSQLMigrationLoader(path string) *SQLMigrationsLoader {
 	}
 }
 
+// SetDialect sets dialect that is used to split loaded migrations
+// into statements, e.g. `sqlscanner.DialectSQLServer` for `GO` batches.
+// By default dialect of the connection is used.
+func (l *SQLMigrationsLoader) SetDialect(d sqlscanner.Dialect) {
+	l.dialect = d
+}
+
+// SetVariables sets variables that are substituted into loaded migrations,
+// see ExpandVariables. Variables can be taken from code, environment
+// (VariablesFromEnv) or config (LoadConfig) and merged with MergeVariables.
+func (l *SQLMigrationsLoader) SetVariables(vars map[string]string) {
+	l.variables = vars
+}
+
+// SetAllowMissingDown allows migrations without down file (or section),
+// such migrations are irreversible. By default Load fails on them.
+func (l *SQLMigrationsLoader) SetAllowMissingDown(allow bool) {
+	l.allowMissingDown = allow
+}
+
+// Load loads `<version>-<name>.up.sql` and `.down.sql` pairs and single
+// `<version>-<name>.sql` files with `-- migo:up` and `-- migo:down` sections.
+// The `migo` directory with templates and config, `seeds` directory,
+// directories starting with `_` (e.g. `_archive` made by Squash)
+// and hook files starting with `_` are skipped. Other `.sql` files
+// with names that are not versions (e.g. `schema.sql`) are skipped too.
 func (l *SQLMigrationsLoader) Load() ([]Migration, error) {
 	files := map[string]os.FileInfo{}
+	singleFiles := map[string]os.FileInfo{}
 
-	removeSuffix := func(s string) string {
-		s = strings.Replace(s, ".up.sql", "", -1)
-		return strings.Replace(s, ".down.sql", "", -1)
-	}
-
-	err := filepath.Walk(l.path, func(path string, info os.FileInfo, err error) error {
-		if strings.HasSuffix(path, ".up.sql") || strings.HasSuffix(path, ".down.sql") {
-			files[removeSuffix(path)] = info
+	err := l.walk(func(path string, info os.FileInfo) {
+		if isSplitFile(path) {
+			files[removeSQLSuffix(path)] = info
+		} else if _, err := VersionFromString(strings.TrimSuffix(info.Name(), ".sql")); err == nil {
+			singleFiles[strings.TrimSuffix(path, ".sql")] = info
 		}
-
-		return nil
 	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	migrations := []Migration{}
 	for fileName, file := range files {
-		version, err := VersionFromString(removeSuffix(file.Name()))
+		version, err := VersionFromString(removeSQLSuffix(file.Name()))
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
 		}
 		migration := SQLMigration{
-			v: *version,
+			v:         *version,
+			Dialect:   l.dialect,
+			Variables: l.variables,
 		}
 
-		upfile, err := os.Open(fileName + ".up.sql")
-		if err != nil {
-			panic(err)
+		migration.UpPath = fileName + ".up.sql"
+		migration.DownPath = fileName + ".down.sql"
+
+		if _, err := os.Stat(migration.UpPath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("up file of migration '%s' is missing", version)
+		} else if err != nil {
+			return nil, err
 		}
 
-		downfile, err := os.Open(fileName + ".down.sql")
-		if err != nil {
-			panic(err)
+		if _, err := os.Stat(migration.DownPath); os.IsNotExist(err) && l.allowMissingDown {
+			migration.DownPath = ""
+		} else if os.IsNotExist(err) {
+			return nil, fmt.Errorf("down file of migration '%s' is missing, add it or allow missing down files", version)
+		} else if err != nil {
+			return nil, err
 		}
 
-		migration.UpFile = upfile
-		migration.DownFile = downfile
+		if err := migration.readHeader(); err != nil {
+			return nil, err
+		}
 
 		migrations = append(migrations, &migration)
 	}
 
+	for fileName, file := range singleFiles {
+		if _, found := files[fileName]; found {
+			return nil, fmt.Errorf("migration '%s' has both single file and up/down files", fileName)
+		}
+
+		version, err := VersionFromString(strings.TrimSuffix(file.Name(), ".sql"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+
+		migration := &SQLMigration{
+			v:         *version,
+			Path:      fileName + ".sql",
+			Dialect:   l.dialect,
+			Variables: l.variables,
+		}
+
+		if err := migration.readHeader(); err != nil {
+			return nil, err
+		}
+
+		if !migration.header.hasDown && !l.allowMissingDown {
+			return nil, fmt.Errorf("down section of migration '%s' is missing, add it or allow missing down sections", version)
+		}
+
+		migrations = append(migrations, migration)
+	}
+
 	return migrations, nil
 }
+
+// walk calls fn for every sql file of migrations.
+func (l *SQLMigrationsLoader) walk(fn func(path string, info os.FileInfo)) error {
+	return filepath.Walk(l.path, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() && path != l.path && (info.Name() == "migo" || info.Name() == SeedsDir || strings.HasPrefix(info.Name(), "_")) {
+			return filepath.SkipDir
+		}
+
+		// files starting with `_` are hooks, see LoadHooks
+		if !info.IsDir() && strings.HasSuffix(path, ".sql") && !strings.HasPrefix(info.Name(), "_") {
+			fn(path, info)
+		}
+
+		return nil
+	})
+}
+
+// Validate reports problems of migration files that Load fails on:
+// unparsable names of up and down files, up or down files without a pair,
+// migrations in both formats and invalid sections of single files.
+// Files skipped by Load (hooks, `schema.sql`) are skipped too.
+// Migrations of all files with valid versions are returned too,
+// even when Load fails on them, so their versions can be checked.
+func (l *SQLMigrationsLoader) Validate() ([]Migration, []ValidationProblem, error) {
+	ups, downs, singles := map[string]string{}, map[string]string{}, map[string]string{}
+	versions := map[string]*Version{}
+	problems := []ValidationProblem{}
+
+	err := l.walk(func(path string, info os.FileInfo) {
+		name := strings.TrimSuffix(removeSQLSuffix(info.Name()), ".sql")
+		version, err := VersionFromString(name)
+		if err != nil && !isSplitFile(path) {
+			// Load skips such files too, e.g. `schema.sql`
+			return
+		}
+		if err != nil {
+			problems = append(problems, ValidationProblem{
+				Kind:    ValidationUnparsable,
+				Sources: []string{path},
+				Message: fmt.Sprintf("can't parse version of '%s': %v", info.Name(), err),
+			})
+			return
+		}
+
+		switch {
+		case strings.HasSuffix(path, ".up.sql"):
+			ups[removeSQLSuffix(path)] = path
+		case strings.HasSuffix(path, ".down.sql"):
+			downs[removeSQLSuffix(path)] = path
+		default:
+			singles[strings.TrimSuffix(path, ".sql")] = path
+		}
+		versions[strings.TrimSuffix(removeSQLSuffix(path), ".sql")] = version
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrations := []Migration{}
+	for key, down := range downs {
+		if _, found := ups[key]; !found {
+			problems = append(problems, ValidationProblem{
+				Kind:    ValidationOrphan,
+				Sources: []string{down},
+				Message: "down file without up file",
+			})
+		}
+	}
+
+	for key, up := range ups {
+		migration := &SQLMigration{
+			v:         *versions[key],
+			UpPath:    up,
+			Dialect:   l.dialect,
+			Variables: l.variables,
+		}
+
+		if down, found := downs[key]; found {
+			migration.DownPath = down
+		} else if !l.allowMissingDown {
+			problems = append(problems, ValidationProblem{
+				Kind:    ValidationOrphan,
+				Sources: []string{up},
+				Message: "up file without down file, add it or allow missing down files",
+			})
+		}
+
+		if err := migration.readHeader(); err != nil {
+			problems = append(problems, ValidationProblem{
+				Kind:    ValidationUnparsable,
+				Sources: []string{up},
+				Message: err.Error(),
+			})
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	for key, single := range singles {
+		if up, found := ups[key]; found {
+			problems = append(problems, ValidationProblem{
+				Kind:    ValidationDuplicate,
+				Sources: []string{single, up},
+				Message: "migration has both single file and up/down files",
+			})
+			continue
+		}
+
+		migrations = append(migrations, &SQLMigration{
+			v:         *versions[key],
+			Path:      single,
+			Dialect:   l.dialect,
+			Variables: l.variables,
+		})
+
+		s, err := readSQLSections(single)
+		if err != nil {
+			problems = append(problems, ValidationProblem{
+				Kind:    ValidationUnparsable,
+				Sources: []string{single},
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		if !s.hasDown && !l.allowMissingDown {
+			problems = append(problems, ValidationProblem{
+				Kind:    ValidationOrphan,
+				Sources: []string{single},
+				Message: "down section is missing, add it or allow missing down sections",
+			})
+		}
+	}
+
+	return migrations, problems, nil
+}
+
+// isSplitFile returns true for `.up.sql` and `.down.sql` files.
+func isSplitFile(path string) bool {
+	return strings.HasSuffix(path, ".up.sql") || strings.HasSuffix(path, ".down.sql")
+}
+
+func removeSQLSuffix(s string) string {
+	s = strings.Replace(s, ".up.sql", "", -1)
+	return strings.Replace(s, ".down.sql", "", -1)
+}
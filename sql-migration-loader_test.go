@@ -6,6 +6,7 @@ import (
 	"path"
 	"runtime"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -56,3 +57,233 @@ func TestSQLMigrationLoader(t *testing.T) {
 		t.Error("bad migrations")
 	}
 }
+
+func TestSQLMigrationLoaderSingleFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "single")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpl := Templater{}
+	err = tmpl.LoadTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"1-create-users.sql":   "-- comment\n-- migo:up\nCREATE TABLE users (id INT);\nINSERT INTO users VALUES (1);\n-- migo:down\nDROP TABLE users;\n",
+		"2-add-email.up.sql":   "ALTER TABLE users ADD email TEXT;",
+		"2-add-email.down.sql": "ALTER TABLE users DROP email;",
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migs, err := NewSQLMigrationLoader(dir).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migs) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(migs))
+	}
+
+	var single Migration
+	for _, mig := range migs {
+		if mig.Version().String() == "1-create-users" {
+			single = mig
+		}
+	}
+
+	c := &ConnectionMock{}
+	single.SetConnection(c)
+	err = single.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = single.Down()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.sqls) != 3 ||
+		c.sqls[0] != "CREATE TABLE users (id INT);" ||
+		c.sqls[2] != "DROP TABLE users;" {
+		t.Errorf("unexpected statements %q", c.sqls)
+	}
+}
+
+func TestParseSQLSections(t *testing.T) {
+	_, err := parseSQLSections([]byte("CREATE TABLE users (id INT);\n-- migo:up\n"))
+	if err == nil {
+		t.Error("statement before up section should fail")
+	}
+
+	_, err = parseSQLSections([]byte("-- migo:down\nDROP TABLE users;\n"))
+	if err != errNoUpSection {
+		t.Errorf("expected errNoUpSection, got %v", err)
+	}
+
+	s, err := parseSQLSections([]byte("-- MIGO:UP\nSELECT 1;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(s.up) != "SELECT 1;\n" || s.hasDown {
+		t.Errorf("unexpected sections %+v", s)
+	}
+}
+
+func TestTemplaterBuildSingleSQL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpl := Templater{}
+	err = tmpl.LoadTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SetSQLFormat(SQLFormatSingle)
+
+	v, _ := VersionFromString("3-add-phone")
+	files, err := tmpl.BuildSQL(v, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, found := files["3-add-phone.sql"]
+	if len(files) != 1 || !found {
+		t.Fatalf("unexpected files %v", files)
+	}
+
+	s, err := parseSQLSections(content)
+	if err != nil || !s.hasDown {
+		t.Errorf("generated migration is not valid: %v", err)
+	}
+}
+
+func TestSQLMigrationLoaderSkipsOtherFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(path.Join(dir, "1-a.up.sql"), []byte("CREATE TABLE a (id INT);"), 0644)
+	ioutil.WriteFile(path.Join(dir, "1-a.down.sql"), []byte("DROP TABLE a;"), 0644)
+	ioutil.WriteFile(path.Join(dir, "schema.sql"), []byte("CREATE TABLE a (id INT);"), 0644)
+	ioutil.WriteFile(path.Join(dir, "_before_all.sql"), []byte("SET search_path TO app;"), 0644)
+
+	loader := NewSQLMigrationLoader(dir)
+	migs, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migs) != 1 || migs[0].Version().String() != "1-a" {
+		t.Errorf("unexpected migrations %v", migs)
+	}
+
+	_, problems, err := loader.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(problems) != 0 {
+		t.Errorf("files skipped by Load should not be reported, got %v", problems)
+	}
+
+	ioutil.WriteFile(path.Join(dir, "create-b.up.sql"), []byte("CREATE TABLE b (id INT);"), 0644)
+	_, problems, err = loader.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(problems) != 1 || problems[0].Kind != ValidationUnparsable {
+		t.Errorf("up file without version should be reported, got %v", problems)
+	}
+}
+
+func TestSQLMigrationLoaderMissingDown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "down")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(path.Join(dir, "1-delete.up.sql"), []byte("DELETE FROM users;"), 0644)
+	ioutil.WriteFile(path.Join(dir, "2-delete.sql"), []byte("-- migo:up\nDELETE FROM users;"), 0644)
+
+	loader := NewSQLMigrationLoader(dir)
+	_, err = loader.Load()
+	if err == nil {
+		t.Error("missing down should fail by default")
+	}
+
+	loader.SetAllowMissingDown(true)
+	migs, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mig := range migs {
+		if !mig.(Irreversible).Irreversible() {
+			t.Errorf("'%s' should be irreversible", mig.Version())
+		}
+	}
+}
+
+func TestTemplaterBuildSQLWithStatements(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpl := Templater{}
+	err = tmpl.LoadTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, _ := VersionFromString("4-add-phone")
+	data := map[string]interface{}{
+		"up":   "ALTER TABLE users ADD COLUMN phone TEXT DEFAULT '';\n",
+		"down": "ALTER TABLE users DROP COLUMN phone;\n",
+	}
+
+	files, err := tmpl.BuildSQL(v, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(files["4-add-phone.up.sql"]) != "-- UP: add-phone\nALTER TABLE users ADD COLUMN phone TEXT DEFAULT '';\n" {
+		t.Errorf("unexpected up file %q", files["4-add-phone.up.sql"])
+	}
+
+	// templates created before `{{.up}}` was available
+	ioutil.WriteFile(path.Join(dir, "migo/tmpl/sql/single.sql"), []byte("-- migo:up\n-- migo:down\n"), 0644)
+	tmpl.SetSQLFormat(SQLFormatSingle)
+
+	files, err = tmpl.BuildSQL(v, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := parseSQLSections(files["4-add-phone.sql"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(s.up), "ADD COLUMN phone") || !strings.Contains(string(s.down), "DROP COLUMN phone") {
+		t.Errorf("statements are not in sections %q", files["4-add-phone.sql"])
+	}
+}
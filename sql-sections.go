@@ -0,0 +1,90 @@
+package migo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	// SQLUpMarker starts up section of a single file sql migration.
+	SQLUpMarker = "-- migo:up"
+	// SQLDownMarker starts down section of a single file sql migration.
+	SQLDownMarker = "-- migo:down"
+)
+
+var errNoUpSection = errors.New("'" + SQLUpMarker + "' section not found")
+
+// sqlSections is a parsed single file sql migration.
+type sqlSections struct {
+	up      []byte
+	down    []byte
+	hasDown bool
+}
+
+func readSQLSections(path string) (*sqlSections, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := parseSQLSections(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// parseSQLSections splits file into up and down sections, only comments
+// are allowed before the first section. Directives are read by readSQLHeader.
+func parseSQLSections(data []byte) (*sqlSections, error) {
+	s := &sqlSections{}
+	var current *bytes.Buffer
+	up, down := &bytes.Buffer{}, &bytes.Buffer{}
+	hasUp := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if d, ok := parseDirective(line); ok {
+			switch d.name {
+			case "up":
+				if hasUp {
+					return nil, fmt.Errorf("line %d: '%s' is repeated", n, SQLUpMarker)
+				}
+				current, hasUp = up, true
+			case "down":
+				if s.hasDown {
+					return nil, fmt.Errorf("line %d: '%s' is repeated", n, SQLDownMarker)
+				}
+				current, s.hasDown = down, true
+			}
+			continue
+		}
+
+		if current == nil {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return nil, fmt.Errorf("line %d: statement before '%s'", n, SQLUpMarker)
+			}
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+
+	if !hasUp {
+		return nil, errNoUpSection
+	}
+
+	s.up, s.down = up.Bytes(), down.Bytes()
+
+	return s, nil
+}
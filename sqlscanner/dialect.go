@@ -0,0 +1,63 @@
+package sqlscanner
+
+import (
+	"io"
+	"sync"
+)
+
+// Dialect is a name of sql dialect. Dialect defines how
+// sql text is split into statements.
+// Names match gorm dialector names.
+type Dialect string
+
+const (
+	DialectDefault   Dialect = ""
+	DialectPostgres  Dialect = "postgres"
+	DialectSQLite    Dialect = "sqlite"
+	DialectMySQL     Dialect = "mysql"
+	DialectSQLServer Dialect = "sqlserver"
+)
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[Dialect]func() SplitFunc{
+		DialectDefault:   NewSemicolonSplitFunc,
+		DialectPostgres:  NewSemicolonSplitFunc,
+		DialectSQLite:    NewSQLiteSplitFunc,
+		DialectMySQL:     NewDelimiterSplitFunc,
+		DialectSQLServer: NewBatchSplitFunc,
+	}
+)
+
+// RegisterDialect sets split function constructor for the dialect.
+// It can be used to add new dialects or to replace splitting
+// strategy of the existing ones.
+func RegisterDialect(d Dialect, newSplitFunc func() SplitFunc) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+
+	dialects[d] = newSplitFunc
+}
+
+// NewSplitFunc returns new split function for the dialect.
+// Unknown dialects are split by semicolons.
+func NewSplitFunc(d Dialect) SplitFunc {
+	dialectsMu.RLock()
+	newSplitFunc, found := dialects[d]
+	dialectsMu.RUnlock()
+
+	if !found {
+		return NewSemicolonSplitFunc()
+	}
+
+	return newSplitFunc()
+}
+
+// NewDialectScanner creates new scanner that splits statements
+// the way it is done for the dialect.
+func NewDialectScanner(r io.Reader, d Dialect) *Scanner {
+	s := NewScanner(r)
+	s.Split(NewSplitFunc(d))
+
+	return s
+}
@@ -0,0 +1,127 @@
+package sqlscanner
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func scanAll(t *testing.T, d Dialect, str string) []string {
+	result := []string{}
+
+	scanner := NewDialectScanner(iotest.HalfReader(strings.NewReader(str)), d)
+	scanner.Buffer(make([]byte, 0, 2), 1024)
+	for scanner.Scan() {
+		result = append(result, scanner.Text())
+	}
+
+	if scanner.Err() != nil {
+		t.Error(scanner.Err())
+	}
+
+	return result
+}
+
+func TestDialectSplitting(t *testing.T) {
+	for _, testCase := range []struct {
+		dialect  Dialect
+		str      string
+		expected []string
+	}{
+		{DialectPostgres, "SELECT 1; SELECT 2;", []string{"SELECT 1;", "SELECT 2;"}},
+		{DialectMySQL, "SELECT 1; SELECT 2;", []string{"SELECT 1;", "SELECT 2;"}},
+		{DialectMySQL, `
+DELIMITER //
+CREATE PROCEDURE p()
+BEGIN
+	SELECT 'a;b';
+	SELECT 1;
+END //
+DELIMITER ;
+CALL p();
+`, []string{"CREATE PROCEDURE p()\nBEGIN\n\tSELECT 'a;b';\n\tSELECT 1;\nEND", "CALL p();"}},
+		{DialectMySQL, `SELECT 'it\'s; fine'; # comment; with 'quote
+SELECT ` + "`a;b`" + `;`, []string{`SELECT 'it\'s; fine';`, "SELECT `a;b`;"}},
+		{DialectMySQL, "delimiter $$\nSELECT 1$$\nSELECT 2 $$", []string{"SELECT 1", "SELECT 2"}},
+		{DialectSQLServer, `
+CREATE TABLE t (id INT);
+INSERT INTO t VALUES (1);
+GO
+-- only comment
+GO
+CREATE PROCEDURE p AS
+BEGIN
+	SELECT 'GO
+GO';
+END
+go
+/*
+GO
+*/
+SELECT 1;
+  GO  
+SELECT 2`, []string{
+			"CREATE TABLE t (id INT);\nINSERT INTO t VALUES (1);",
+			"CREATE PROCEDURE p AS\nBEGIN\n\tSELECT 'GO\nGO';\nEND",
+			"SELECT 1;",
+			"SELECT 2",
+		}},
+		{DialectSQLServer, "SELECT 1\nGOTO label\nGO", []string{"SELECT 1\nGOTO label"}},
+		{DialectSQLServer, "GO\n\nGO\n", []string{}},
+		{DialectSQLite, `
+CREATE TABLE t (id INTEGER, updated TEXT);
+CREATE TRIGGER t_updated AFTER UPDATE ON t
+BEGIN
+	UPDATE t SET updated = CASE WHEN new.id > 0 THEN 'end;' ELSE "x" END WHERE id = new.id;
+	-- end;
+	SELECT 1;
+END;
+begin;
+SELECT 'begin';
+create temp trigger t_deleted before delete on t begin select raise(abort, 'no'); end;
+END;`, []string{
+			"CREATE TABLE t (id INTEGER, updated TEXT);",
+			"CREATE TRIGGER t_updated AFTER UPDATE ON t\nBEGIN\n\tUPDATE t SET updated = CASE WHEN new.id > 0 THEN 'end;' ELSE \"x\" END WHERE id = new.id;\n\t-- end;\n\tSELECT 1;\nEND;",
+			"begin;",
+			"SELECT 'begin';",
+			"create temp trigger t_deleted before delete on t begin select raise(abort, 'no'); end;",
+			"END;",
+		}},
+	} {
+		actualResult := scanAll(t, testCase.dialect, testCase.str)
+		if len(actualResult) != len(testCase.expected) {
+			t.Errorf("%s: expected: %q; actual: %q", testCase.dialect, testCase.expected, actualResult)
+			continue
+		}
+
+		for i := 0; i < len(actualResult); i++ {
+			if actualResult[i] != testCase.expected[i] {
+				t.Errorf("%s: expected: %q; actual: %q", testCase.dialect, testCase.expected[i], actualResult[i])
+			}
+		}
+	}
+}
+
+func TestDelimiterWithoutValue(t *testing.T) {
+	scanner := NewDialectScanner(strings.NewReader("DELIMITER \nSELECT 1;"), DialectMySQL)
+	for scanner.Scan() {
+	}
+
+	if scanner.Err() != errEmptyDelimiter {
+		t.Errorf("expected: %v; actual: %v", errEmptyDelimiter, scanner.Err())
+	}
+}
+
+func TestRegisterDialect(t *testing.T) {
+	RegisterDialect("custom", NewBatchSplitFunc)
+	defer func() {
+		dialectsMu.Lock()
+		delete(dialects, "custom")
+		dialectsMu.Unlock()
+	}()
+
+	result := scanAll(t, "custom", "SELECT 1; SELECT 2;\nGO\n")
+	if len(result) != 1 || result[0] != "SELECT 1; SELECT 2;" {
+		t.Errorf("unexpected result: %q", result)
+	}
+}
@@ -0,0 +1,135 @@
+package sqlscanner
+
+import (
+	"bytes"
+)
+
+// batchSplitter splits input into batches separated with `GO` lines,
+// the way sql server tools do.
+type batchSplitter struct {
+	lex       lexer
+	pos       int
+	start     int
+	lineStart bool
+}
+
+// NewBatchSplitFunc returns split function that splits input into batches
+// separated with `GO` written on its own line. Batches can contain many
+// statements and are returned without the separator. Text after the last
+// separator is returned as the last batch. Batches that contain only
+// whitespaces and comments are skipped.
+// Split function keeps state, so it can't be shared between scanners.
+func NewBatchSplitFunc() SplitFunc {
+	s := &batchSplitter{}
+	s.reset()
+
+	return s.split
+}
+
+func (s *batchSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	for s.pos < len(data) {
+		if s.lineStart && s.lex.inCode() {
+			end, ok := matchBatchSeparator(data, s.pos, atEOF)
+			if !ok {
+				return s.more()
+			}
+
+			if end > 0 {
+				token := s.token(data, s.pos)
+				s.reset()
+
+				return end, token, nil
+			}
+
+			s.lineStart = false
+		}
+
+		c := data[s.pos]
+		if s.lex.inCode() && s.start < 0 && isSpace(c) {
+			s.lineStart = c == '\n'
+			s.pos++
+			continue
+		}
+
+		wasCode := s.lex.inCode()
+		next := s.lex.advance(data, s.pos, atEOF)
+		if next < 0 {
+			return s.more()
+		}
+
+		if s.start < 0 && wasCode && !s.lex.inComment() {
+			s.start = s.pos
+		}
+
+		s.lineStart = data[next-1] == '\n' && s.lex.inCode()
+		s.pos = next
+	}
+
+	if atEOF {
+		token := s.token(data, len(data))
+		s.reset()
+
+		return len(data), token, nil
+	}
+
+	return s.more()
+}
+
+func (s *batchSplitter) token(data []byte, end int) []byte {
+	if s.start < 0 {
+		return nil
+	}
+
+	return bytes.TrimRight(data[s.start:end], " \t\r\n")
+}
+
+func (s *batchSplitter) more() (int, []byte, error) {
+	if s.start >= 0 {
+		return 0, nil, nil
+	}
+
+	advance := s.pos
+	s.pos = 0
+
+	return advance, nil, nil
+}
+
+func (s *batchSplitter) reset() {
+	s.lex.state = stateCode
+	s.pos = 0
+	s.start = -1
+	s.lineStart = true
+}
+
+// matchBatchSeparator checks if line that starts at `pos` is a `GO` separator.
+// Returns position after the separator line or 0 if there is no separator.
+// `ok` is false when more data is needed.
+func matchBatchSeparator(data []byte, pos int, atEOF bool) (end int, ok bool) {
+	i := pos
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t') {
+		i++
+	}
+
+	if len(data)-i < 2 {
+		return 0, atEOF
+	}
+
+	if (data[i] != 'g' && data[i] != 'G') || (data[i+1] != 'o' && data[i+1] != 'O') {
+		return 0, true
+	}
+
+	i += 2
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\r') {
+		i++
+	}
+
+	if i == len(data) {
+		return i, atEOF
+	}
+
+	if data[i] != '\n' {
+		return 0, true
+	}
+
+	return i + 1, true
+}
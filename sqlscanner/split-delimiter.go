@@ -0,0 +1,178 @@
+package sqlscanner
+
+import (
+	"bytes"
+	"errors"
+)
+
+var (
+	delimiterCommand = []byte("delimiter")
+
+	errEmptyDelimiter = errors.New("DELIMITER command without delimiter")
+)
+
+// delimiterSplitter splits input into statements the way mysql client does.
+// Statements are terminated with `;` until `DELIMITER <new delimiter>`
+// line changes the terminator.
+type delimiterSplitter struct {
+	lex       lexer
+	delimiter []byte
+	pos       int
+	start     int
+}
+
+// NewDelimiterSplitFunc returns split function that understands
+// mysql `DELIMITER` command. `DELIMITER` lines are not returned as
+// statements. Statements terminated with `;` contain it, custom
+// delimiters are cut off, because server doesn't understand them.
+// Split function keeps state, so it can't be shared between scanners.
+func NewDelimiterSplitFunc() SplitFunc {
+	s := &delimiterSplitter{
+		lex: lexer{
+			mysql: true,
+		},
+		delimiter: []byte(";"),
+		start:     -1,
+	}
+
+	return s.split
+}
+
+func (s *delimiterSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	for s.pos < len(data) {
+		c := data[s.pos]
+		if s.lex.inCode() {
+			if s.start < 0 {
+				if isSpace(c) {
+					s.pos++
+					continue
+				}
+
+				end, delimiter, ok := matchDelimiterCommand(data, s.pos, atEOF)
+				if !ok {
+					return s.more()
+				}
+
+				if end > 0 {
+					if len(delimiter) == 0 {
+						return 0, nil, errEmptyDelimiter
+					}
+
+					s.delimiter = delimiter
+					s.pos = end
+					continue
+				}
+			}
+
+			found, ok := hasPrefixAt(data, s.pos, s.delimiter, atEOF)
+			if !ok {
+				return s.more()
+			}
+
+			if found {
+				if s.start < 0 {
+					s.pos += len(s.delimiter)
+					continue
+				}
+
+				token := data[s.start:s.pos]
+				if bytes.Equal(s.delimiter, []byte(";")) {
+					token = data[s.start : s.pos+1]
+				} else {
+					token = bytes.TrimRight(token, " \t\r\n")
+				}
+
+				advance := s.pos + len(s.delimiter)
+				s.reset()
+
+				return advance, token, nil
+			}
+		}
+
+		wasCode := s.lex.inCode()
+		next := s.lex.advance(data, s.pos, atEOF)
+		if next < 0 {
+			return s.more()
+		}
+
+		if s.start < 0 && wasCode && !s.lex.inComment() {
+			s.start = s.pos
+		}
+
+		s.pos = next
+	}
+
+	if atEOF {
+		s.reset()
+		return len(data), nil, nil
+	}
+
+	return s.more()
+}
+
+func (s *delimiterSplitter) more() (int, []byte, error) {
+	if s.start >= 0 {
+		return 0, nil, nil
+	}
+
+	advance := s.pos
+	s.pos = 0
+
+	return advance, nil, nil
+}
+
+func (s *delimiterSplitter) reset() {
+	s.lex.state = stateCode
+	s.pos = 0
+	s.start = -1
+}
+
+// matchDelimiterCommand checks if `DELIMITER` command starts at `pos`.
+// Returns position after the command line and the new delimiter,
+// `end` is 0 when there is no command. `ok` is false when more data is needed.
+func matchDelimiterCommand(data []byte, pos int, atEOF bool) (end int, delimiter []byte, ok bool) {
+	rest := data[pos:]
+	if len(rest) <= len(delimiterCommand) {
+		if !atEOF && bytes.HasPrefix(delimiterCommand, bytes.ToLower(rest)) {
+			return 0, nil, false
+		}
+
+		return 0, nil, true
+	}
+
+	if !bytes.EqualFold(rest[:len(delimiterCommand)], delimiterCommand) ||
+		(rest[len(delimiterCommand)] != ' ' && rest[len(delimiterCommand)] != '\t') {
+		return 0, nil, true
+	}
+
+	lineEnd := bytes.IndexByte(rest, '\n')
+	if lineEnd < 0 {
+		if !atEOF {
+			return 0, nil, false
+		}
+		lineEnd = len(rest)
+	}
+
+	fields := bytes.Fields(rest[len(delimiterCommand):lineEnd])
+	if len(fields) > 0 {
+		delimiter = append([]byte{}, fields[0]...)
+	}
+
+	end = pos + lineEnd
+	if lineEnd < len(rest) {
+		end++
+	}
+
+	return end, delimiter, true
+}
+
+// hasPrefixAt checks if `prefix` starts at `pos`.
+// `ok` is false when more data is needed.
+func hasPrefixAt(data []byte, pos int, prefix []byte, atEOF bool) (found bool, ok bool) {
+	rest := data[pos:]
+	if len(rest) < len(prefix) {
+		return false, atEOF || !bytes.HasPrefix(prefix, rest)
+	}
+
+	return bytes.HasPrefix(rest, prefix), true
+}
@@ -0,0 +1,266 @@
+package sqlscanner
+
+import "strings"
+
+type lexState int
+
+const (
+	stateCode lexState = iota
+	stateSingleQuote
+	stateDoubleQuote
+	stateLineComment
+	stateBlockComment
+	stateBacktick
+)
+
+// lexer tracks whether current position of sql text is
+// inside of a string literal, a quoted identifier or a comment.
+// With `mysql` flag it also understands backslash escapes,
+// `#` comments and backtick quoted identifiers.
+type lexer struct {
+	state lexState
+	mysql bool
+}
+
+// advance moves through a single token that starts at `pos`
+// and returns position right after it.
+// Returns -1 when more data is needed to make a decision.
+func (l *lexer) advance(data []byte, pos int, atEOF bool) int {
+	c := data[pos]
+	switch l.state {
+	case stateCode:
+		switch c {
+		case '\'':
+			l.state = stateSingleQuote
+		case '"':
+			l.state = stateDoubleQuote
+		case '`':
+			if l.mysql {
+				l.state = stateBacktick
+			}
+		case '#':
+			if l.mysql {
+				l.state = stateLineComment
+			}
+		case '-', '/':
+			if pos+1 >= len(data) {
+				if !atEOF {
+					return -1
+				}
+				break
+			}
+
+			if c == '-' && data[pos+1] == '-' {
+				l.state = stateLineComment
+				return pos + 2
+			}
+
+			if c == '/' && data[pos+1] == '*' {
+				l.state = stateBlockComment
+				return pos + 2
+			}
+		}
+	case stateSingleQuote, stateDoubleQuote:
+		if c == '\\' && l.mysql {
+			if pos+1 >= len(data) {
+				if !atEOF {
+					return -1
+				}
+				break
+			}
+
+			return pos + 2
+		}
+
+		if (c == '\'' && l.state == stateSingleQuote) || (c == '"' && l.state == stateDoubleQuote) {
+			l.state = stateCode
+		}
+	case stateBacktick:
+		if c == '`' {
+			l.state = stateCode
+		}
+	case stateLineComment:
+		if c == '\n' {
+			l.state = stateCode
+		}
+	case stateBlockComment:
+		if c == '*' {
+			if pos+1 >= len(data) {
+				if !atEOF {
+					return -1
+				}
+				break
+			}
+
+			if data[pos+1] == '/' {
+				l.state = stateCode
+				return pos + 2
+			}
+		}
+	}
+
+	return pos + 1
+}
+
+func (l *lexer) inCode() bool {
+	return l.state == stateCode
+}
+
+func (l *lexer) inComment() bool {
+	return l.state == stateLineComment || l.state == stateBlockComment
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
+	}
+
+	return false
+}
+
+// semicolonSplitter splits input into statements terminated with `;`.
+// Whitespaces and comments between statements are skipped,
+// text after the last `;` is ignored.
+// Scanning state is kept between calls, so data that was already
+// checked is never scanned twice, even for huge statements.
+type semicolonSplitter struct {
+	lex   lexer
+	pos   int
+	start int
+
+	// triggers enables `CREATE TRIGGER ... BEGIN ... END;` statements,
+	// `;` inside of the trigger body doesn't end statement.
+	triggers bool
+	words    int
+	create   bool
+	trigger  bool
+	depth    int
+}
+
+// NewSemicolonSplitFunc returns split function that splits input
+// into statements terminated with `;`. Returned statements contain
+// the terminating `;`. Split function keeps state, so it can't be
+// shared between scanners.
+func NewSemicolonSplitFunc() SplitFunc {
+	s := &semicolonSplitter{
+		start: -1,
+	}
+
+	return s.split
+}
+
+// NewSQLiteSplitFunc returns split function that splits input by `;`
+// like NewSemicolonSplitFunc, but keeps bodies of `CREATE TRIGGER`
+// statements (`BEGIN ... END;`) in a single statement.
+func NewSQLiteSplitFunc() SplitFunc {
+	s := &semicolonSplitter{
+		start:    -1,
+		triggers: true,
+	}
+
+	return s.split
+}
+
+func (s *semicolonSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	for s.pos < len(data) {
+		c := data[s.pos]
+		if s.lex.inCode() {
+			if s.start < 0 && (isSpace(c) || c == ';') {
+				s.pos++
+				continue
+			}
+
+			if s.triggers && isWordStart(c) {
+				end := s.pos
+				for end < len(data) && isWordPart(data[end]) {
+					end++
+				}
+				if end == len(data) && !atEOF {
+					return s.more()
+				}
+
+				if s.start < 0 {
+					s.start = s.pos
+				}
+				s.word(data[s.pos:end])
+				s.pos = end
+				continue
+			}
+
+			if c == ';' && s.depth == 0 {
+				token := data[s.start : s.pos+1]
+				advance := s.pos + 1
+				s.reset()
+
+				return advance, token, nil
+			}
+		}
+
+		wasCode := s.lex.inCode()
+		next := s.lex.advance(data, s.pos, atEOF)
+		if next < 0 {
+			return s.more()
+		}
+
+		if s.start < 0 && wasCode && !s.lex.inComment() {
+			s.start = s.pos
+		}
+
+		s.pos = next
+	}
+
+	if atEOF {
+		s.reset()
+		return len(data), nil, nil
+	}
+
+	return s.more()
+}
+
+// more asks for more data. Skipped whitespaces and comments are
+// dropped from the buffer, so they don't take memory.
+func (s *semicolonSplitter) more() (int, []byte, error) {
+	if s.start >= 0 {
+		return 0, nil, nil
+	}
+
+	advance := s.pos
+	s.pos = 0
+
+	return advance, nil, nil
+}
+
+func (s *semicolonSplitter) reset() {
+	s.lex.state = stateCode
+	s.pos = 0
+	s.start = -1
+	s.words = 0
+	s.create = false
+	s.trigger = false
+	s.depth = 0
+}
+
+// word tracks keywords of the current statement: `CREATE [TEMP] TRIGGER`
+// starts trigger, `BEGIN` and `CASE` of its body open a block, `END` closes it.
+func (s *semicolonSplitter) word(w []byte) {
+	s.words++
+	switch {
+	case s.words == 1:
+		s.create = strings.EqualFold(string(w), "CREATE")
+	case s.create && s.words <= 3 && strings.EqualFold(string(w), "TRIGGER"):
+		s.trigger = true
+	case s.trigger && (strings.EqualFold(string(w), "BEGIN") || strings.EqualFold(string(w), "CASE")):
+		s.depth++
+	case s.trigger && s.depth > 0 && strings.EqualFold(string(w), "END"):
+		s.depth--
+	}
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isWordPart(c byte) bool {
+	return isWordStart(c) || (c >= '0' && c <= '9') || c == '$'
+}
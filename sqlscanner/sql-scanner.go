@@ -1,21 +1,94 @@
 package sqlscanner
 
 import (
-	"errors"
+	"bufio"
 	"io"
-	"strings"
 )
 
-var (
-	errorEndOfFile = errors.New("end of file")
+const (
+	// MaxStatementSize is the default maximum size of a single statement
+	// that Scanner is able to buffer. Use Scanner.Buffer to change it.
+	MaxStatementSize = 64 * 1024 * 1024
+
+	initialBufferSize = 64 * 1024
 )
 
-// SQLScanner reads reader and extracts sql queries from it
+// SplitFunc is the signature of the function used to split
+// input into statements, it follows bufio.SplitFunc contract.
+type SplitFunc = bufio.SplitFunc
+
+// Scanner reads reader and extracts sql statements from it.
+// Input is read through a buffer, so memory usage depends only
+// on the size of the longest statement, not on the size of the input.
+// Usage sample:
+//
+//	scanner := NewScanner(reader)
+//	for scanner.Scan() {
+//		query := scanner.Text()
+//		// do something with query
+//	}
+//
+//	if err := scanner.Err(); err != nil {
+//		// handle error
+//	}
+type Scanner struct {
+	s *bufio.Scanner
+}
+
+// NewScanner creates new scanner with given reader.
+// By default statements are separated by semicolons.
+func NewScanner(r io.Reader) *Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, initialBufferSize), MaxStatementSize)
+	s.Split(NewSemicolonSplitFunc())
+
+	return &Scanner{
+		s: s,
+	}
+}
+
+// Split sets the split function for the scanner.
+// Panics if it is called after scanning has started.
+func (s *Scanner) Split(split SplitFunc) {
+	s.s.Split(split)
+}
+
+// Buffer sets the initial buffer and the maximum size of a statement.
+// Panics if it is called after scanning has started.
+func (s *Scanner) Buffer(buf []byte, max int) {
+	s.s.Buffer(buf, max)
+}
+
+// Scan advances the scanner to the next statement, which will then be
+// available through the Text or Bytes methods. Returns `false` when
+// reader ended or when error occures.
+func (s *Scanner) Scan() bool {
+	return s.s.Scan()
+}
+
+// Text returns the most recent statement found by Scan.
+func (s *Scanner) Text() string {
+	return s.s.Text()
+}
+
+// Bytes returns the most recent statement found by Scan.
+// The underlying array may be overwritten by the next call to Scan.
+func (s *Scanner) Bytes() []byte {
+	return s.s.Bytes()
+}
+
+// Err returns the first non-EOF error that was encountered by the Scanner.
+func (s *Scanner) Err() error {
+	return s.s.Err()
+}
+
+// SQLScanner reads reader and extracts sql queries from it.
+//
+// Deprecated: use Scanner, it splits statements the same way
+// and mirrors bufio.Scanner API.
 type SQLScanner struct {
-	r     io.Reader
+	s     *Scanner
 	Error error
-	buf   []byte
-	bufI  int
 }
 
 // NewSQLScanner creates new sql scanner with given reader.
@@ -26,14 +99,14 @@ type SQLScanner struct {
 //		var query string
 //		for sqlReader.Next(&query) {
 //			// do something with query
-// 		}
+//		}
 //
-// 		if sqlReader.Error != nil {
+//		if sqlReader.Error != nil {
 //			// handle error
 //		}
 func NewSQLScanner(r io.Reader) SQLScanner {
 	return SQLScanner{
-		r: r,
+		s: NewScanner(r),
 	}
 }
 
@@ -42,170 +115,12 @@ func NewSQLScanner(r io.Reader) SQLScanner {
 // Returns `true` if query found and written to the `sqlResult`.
 // Returns `false` when reader ended or when error occures.
 func (s *SQLScanner) Next(sqlResult *string) bool {
-	defer func() {
-		if s.Error == errorEndOfFile || s.Error == io.EOF {
-			s.Error = nil
-		}
-	}()
-
-	s.Error = s.skipTillBeginning()
-	if s.Error != nil {
+	if !s.s.Scan() {
+		s.Error = s.s.Err()
 		return false
 	}
 
-	result := ""
-
-	for {
-		p, err := s.indexOf(";")
-		if err != nil {
-			s.Error = err
-			return false
-		}
-
-		if p < 0 {
-			return false
-		}
-
-		d, err := s.pop(p + 1)
-		if err != nil {
-			s.Error = err
-			return false
-		}
-
-		result += string(d)
-
-		count := strings.Count(result, "'")
-		if count == 0 || count%2 == 0 {
-			break
-		}
-
-	}
-
-	*sqlResult = result
+	*sqlResult = s.s.Text()
 
 	return true
 }
-
-func (s *SQLScanner) skipTillBeginning() error {
-	for {
-		d, err := s.peek(2)
-		if err != nil {
-			return err
-		}
-
-		if len(d) < 2 {
-			return errorEndOfFile
-		}
-
-		switch string(d[0]) {
-		case "\n", "\t", " ":
-			s.skip(0, 1)
-			continue
-		}
-
-		if string(d) == "--" {
-			s.skip(0, 2)
-			s.skipUntilFound("\n")
-			continue
-		}
-
-		return nil
-	}
-}
-
-func (s *SQLScanner) peek(l int) ([]byte, error) {
-	if len(s.buf) < l {
-		r, err := s.read(l)
-		if err != nil && err != errorEndOfFile {
-			return nil, err
-		}
-		s.buf = append(s.buf, r...)
-	}
-
-	maxLen := len(s.buf) - s.bufI
-	if maxLen > l {
-		maxLen = l
-	}
-
-	return s.buf[s.bufI:maxLen], nil
-}
-
-func (s *SQLScanner) pop(l int) ([]byte, error) {
-	if len(s.buf) < l {
-		r, err := s.read(l)
-		if err != nil && err != errorEndOfFile {
-			return nil, err
-		}
-		s.buf = append(s.buf, r...)
-	}
-
-	maxLen := len(s.buf) - s.bufI
-	if maxLen > l {
-		maxLen = l
-	}
-
-	result := s.buf[s.bufI:maxLen]
-
-	s.buf = s.buf[s.bufI+maxLen:]
-	s.bufI = 0
-	return result, nil
-}
-
-func (s *SQLScanner) skip(start, l int) {
-	s.buf = append(s.buf[0:start], s.buf[l:]...)
-}
-
-func (s *SQLScanner) indexOf(str string) (int, error) {
-	i := 0
-	readSize := 0
-	for {
-		readSize = i + len(str)*2
-		d, err := s.peek(readSize)
-		if err != nil {
-			return -1, err
-		}
-
-		if len(d) == i {
-			return -1, nil
-		}
-
-		p := strings.Index(string(d), str)
-		if p >= 0 {
-			return p, nil
-		}
-		i = readSize
-	}
-}
-
-func (s *SQLScanner) skipUntilFound(str string) error {
-	for {
-		d, err := s.peek(len(str) * 2)
-		if err != nil {
-			return err
-		}
-		p := strings.Index(string(d), str)
-		if p < 0 {
-			s.skip(0, len(d))
-			continue
-		} else if p == 0 {
-			s.skip(0, 1)
-		} else {
-			s.skip(0, p)
-		}
-		return nil
-	}
-}
-
-func (s *SQLScanner) read(l int) ([]byte, error) {
-	b := make([]byte, l)
-	n, err := s.r.Read(b)
-	if err != nil {
-		return nil, err
-	}
-
-	if n < 1 {
-		return nil, errorEndOfFile
-	}
-
-	return b, nil
-}
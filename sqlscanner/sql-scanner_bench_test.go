@@ -0,0 +1,64 @@
+package sqlscanner
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func manySmallStatements(n int) []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("-- seed data\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(buf, "INSERT INTO users (id, name) VALUES (%d, 'user; %d');\n", i, i)
+	}
+
+	return buf.Bytes()
+}
+
+func oneLargeStatement(rows int) []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("INSERT INTO users (id, name) VALUES\n")
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		fmt.Fprintf(buf, "(%d, 'user; %d')", i, i)
+	}
+	buf.WriteString(";\n")
+
+	return buf.Bytes()
+}
+
+func benchmarkScanner(b *testing.B, data []byte) {
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		scanner := NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			_ = scanner.Bytes()
+		}
+
+		if scanner.Err() != nil {
+			b.Fatal(scanner.Err())
+		}
+	}
+}
+
+func BenchmarkScannerSmallStatements1K(b *testing.B) {
+	benchmarkScanner(b, manySmallStatements(1000))
+}
+
+func BenchmarkScannerSmallStatements100K(b *testing.B) {
+	benchmarkScanner(b, manySmallStatements(100000))
+}
+
+func BenchmarkScannerLargeStatement1K(b *testing.B) {
+	benchmarkScanner(b, oneLargeStatement(1000))
+}
+
+func BenchmarkScannerLargeStatement100K(b *testing.B) {
+	benchmarkScanner(b, oneLargeStatement(100000))
+}
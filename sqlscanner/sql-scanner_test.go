@@ -1,8 +1,10 @@
 package sqlscanner
 
 import (
+	"bufio"
 	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestTable(t *testing.T) {
@@ -140,3 +142,79 @@ CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public;
 		}
 	}
 }
+
+func TestScanner(t *testing.T) {
+	for _, testCase := range []struct {
+		str      string
+		expected []string
+	}{
+		{"SELECT 1;SELECT 2;", []string{"SELECT 1;", "SELECT 2;"}},
+		{"\r\n\tSELECT 1;\r\n", []string{"SELECT 1;"}},
+		{";;SELECT 1;;", []string{"SELECT 1;"}},
+		{"INSERT INTO t VALUES ('it''s; fine');", []string{"INSERT INTO t VALUES ('it''s; fine');"}},
+		{`SELECT "weird;name" FROM t;`, []string{`SELECT "weird;name" FROM t;`}},
+		{"/* header; */ SELECT 1;", []string{"SELECT 1;"}},
+		{"SELECT 1 /* it's; */ + 1;", []string{"SELECT 1 /* it's; */ + 1;"}},
+		{"SELECT 1 -- don't;\n;", []string{"SELECT 1 -- don't;\n;"}},
+		{"-- only comment", []string{}},
+		{"SELECT 1; -- tail comment", []string{"SELECT 1;"}},
+		{"SELECT 1 - 1;", []string{"SELECT 1 - 1;"}},
+		{"SELECT 4 / 2;", []string{"SELECT 4 / 2;"}},
+	} {
+		actualResult := []string{}
+
+		scanner := NewScanner(strings.NewReader(testCase.str))
+		for scanner.Scan() {
+			actualResult = append(actualResult, scanner.Text())
+		}
+
+		if scanner.Err() != nil {
+			t.Error(scanner.Err())
+		}
+
+		if len(actualResult) != len(testCase.expected) {
+			t.Errorf("expected: %q; actual: %q", testCase.expected, actualResult)
+			continue
+		}
+
+		for i := 0; i < len(actualResult); i++ {
+			if actualResult[i] != testCase.expected[i] {
+				t.Errorf("expected: %v; actual: %v", testCase.expected[i], actualResult[i])
+			}
+		}
+	}
+}
+
+func TestScannerSmallReads(t *testing.T) {
+	source := "-- header\nINSERT INTO t VALUES ('a;b');\n/* c */ SELECT 1 - 1;\n"
+	expectedResult := []string{"INSERT INTO t VALUES ('a;b');", "SELECT 1 - 1;"}
+
+	scanner := NewScanner(iotest.OneByteReader(strings.NewReader(source)))
+	scanner.Buffer(make([]byte, 0, 1), 1024)
+
+	actualResult := []string{}
+	for scanner.Scan() {
+		actualResult = append(actualResult, scanner.Text())
+	}
+
+	if scanner.Err() != nil {
+		t.Error(scanner.Err())
+	}
+
+	if strings.Join(actualResult, "|") != strings.Join(expectedResult, "|") {
+		t.Errorf("expected: %q; actual: %q", expectedResult, actualResult)
+	}
+}
+
+func TestScannerStatementTooLong(t *testing.T) {
+	scanner := NewScanner(strings.NewReader("SELECT '" + strings.Repeat("a", 128) + "';"))
+	scanner.Buffer(make([]byte, 0, 16), 64)
+
+	for scanner.Scan() {
+		t.Error("statement should not fit into the buffer")
+	}
+
+	if scanner.Err() != bufio.ErrTooLong {
+		t.Errorf("expected: %v; actual: %v", bufio.ErrTooLong, scanner.Err())
+	}
+}
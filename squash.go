@@ -0,0 +1,275 @@
+package migo
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrSchemaDumpNotSupported is returned by Squash when scratch connection
+// doesn't implement SchemaDumper and SquashOptions.Dump is not set.
+var ErrSchemaDumpNotSupported = errors.New("connection can't dump schema")
+
+// ArchiveDir is the directory where Squash moves squashed sql migrations,
+// it is created next to the migration files.
+const ArchiveDir = "_archive"
+
+// SquashOptions configures Squash.
+type SquashOptions struct {
+	// Until is the version of the last squashed migration.
+	Until string
+	// Scratch is a connection to an empty database where migrations are replayed.
+	Scratch Connection
+	// Dir is the directory where baseline migration is written.
+	Dir string
+	// Dump returns schema of the scratch database as sql script,
+	// by default scratch connection must implement SchemaDumper.
+	Dump func(c Connection) (string, error)
+}
+
+// SquashResult describes result of Squash.
+type SquashResult struct {
+	// Baseline is the path of created baseline migration.
+	Baseline string
+	// Superseded are versions replaced by the baseline.
+	Superseded []string
+	// Archived are moved sql files.
+	Archived []string
+	// Remaining are superseded go migrations, they are ignored
+	// and can be removed from code.
+	Remaining []string
+}
+
+// Squash replays migrations up to and including `opts.Until` on scratch
+// database, writes its schema into a single baseline migration and
+// archives squashed sql files. Baseline supersedes squashed migrations,
+// so databases that have them applied just record baseline as applied.
+func (m *Migrate) Squash(opts SquashOptions) (*SquashResult, error) {
+	until, err := versionWithOptionalName(opts.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Scratch == nil {
+		return nil, errors.New("scratch connection is required")
+	}
+
+	dump := opts.Dump
+	if dump == nil {
+		if _, ok := opts.Scratch.(SchemaDumper); !ok {
+			return nil, ErrSchemaDumpNotSupported
+		}
+
+		dump = func(c Connection) (string, error) {
+			return c.(SchemaDumper).DumpSchema()
+		}
+	}
+
+	err = m.loadMigrations()
+	if err != nil {
+		return nil, errors.New("can't load migrations " + err.Error())
+	}
+
+	squashed := []Migration{}
+	found := false
+	for _, migration := range m.sort(m.migrations, true) {
+		v := migration.Version()
+		if v.GreaterThan(until) {
+			break
+		}
+
+		if v.StringWithoutName() == until.StringWithoutName() {
+			found = true
+		}
+
+		squashed = append(squashed, migration)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("migration with version '%s' not found", until.StringWithoutName())
+	}
+
+	fmt.Fprintf(m.output(), "Replaying %d migration(s) on scratch database...\n", len(squashed))
+
+	err = replay(opts.Scratch, squashed)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := dump(opts.Scratch)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SquashResult{
+		Baseline: filepath.Join(opts.Dir, until.StringWithoutName()+"-squashed.sql"),
+	}
+
+	err = ioutil.WriteFile(res.Baseline, []byte(baselineContent(until, schema)), 0644)
+	if err != nil {
+		return res, err
+	}
+
+	for _, migration := range squashed {
+		res.Superseded = append(res.Superseded, migration.Version().String())
+
+		sqlMigration, ok := unwrapMigration(migration).(*SQLMigration)
+		if !ok || (sqlMigration.UpPath == "" && sqlMigration.Path == "") {
+			res.Remaining = append(res.Remaining, migration.Version().String())
+			continue
+		}
+
+		for _, path := range []string{sqlMigration.UpPath, sqlMigration.DownPath, sqlMigration.Path} {
+			if path == "" {
+				continue
+			}
+
+			archived, err := archiveFile(path)
+			if err != nil {
+				return res, err
+			}
+
+			res.Archived = append(res.Archived, archived)
+		}
+	}
+
+	fmt.Fprintf(m.output(), "Squashed %d migration(s) into '%s'!\n", len(squashed), res.Baseline)
+
+	return res, nil
+}
+
+func baselineContent(until *Version, schema string) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "-- Baseline of migrations up to %s, created by migo squash.\n", until.StringWithoutName())
+	fmt.Fprintf(b, "%s%s %s\n", DirectivePrefix, directiveSupersedes, until.StringWithoutName())
+	fmt.Fprintf(b, "%s%s\n", DirectivePrefix, directiveIrreversible)
+	fmt.Fprintf(b, "%s\n", SQLUpMarker)
+	b.WriteString(strings.TrimSpace(schema))
+	fmt.Fprintf(b, "\n\n%s\n", SQLDownMarker)
+
+	return b.String()
+}
+
+func archiveFile(path string) (string, error) {
+	dir := filepath.Join(filepath.Dir(path), ArchiveDir)
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+
+	archived := filepath.Join(dir, filepath.Base(path))
+
+	return archived, os.Rename(path, archived)
+}
+
+// versionWithOptionalName parses version that can be given without name, e.g. `3.2.0`.
+func versionWithOptionalName(s string) (*Version, error) {
+	if !strings.Contains(s, "-") {
+		s += "-"
+	}
+
+	return VersionFromString(s)
+}
+
+// dropSuperseded removes migrations that are replaced by a Superseder.
+func dropSuperseded(ms []Migration) []Migration {
+	type superseder struct {
+		m     Migration
+		until *Version
+	}
+
+	superseders := []superseder{}
+	for _, migration := range ms {
+		s, ok := unwrapMigration(migration).(Superseder)
+		if !ok || s.Supersedes() == "" {
+			continue
+		}
+
+		until, err := versionWithOptionalName(s.Supersedes())
+		if err != nil {
+			continue
+		}
+
+		superseders = append(superseders, superseder{m: migration, until: until})
+	}
+
+	if len(superseders) == 0 {
+		return ms
+	}
+
+	result := []Migration{}
+	for _, migration := range ms {
+		superseded := false
+		for _, s := range superseders {
+			v := migration.Version()
+			if migration != s.m && s.until.GreaterThanOrEqual(&v) {
+				superseded = true
+				break
+			}
+		}
+
+		if !superseded {
+			result = append(result, migration)
+		}
+	}
+
+	return result
+}
+
+// recordSuperseders records superseders as applied without running them
+// on databases that have superseded migrations applied.
+// Returns applied versions including recorded ones.
+func (m *Migrate) recordSuperseders(applied []Version) ([]Version, error) {
+	zero, _ := VersionFromString("0-")
+
+	for _, migration := range m.migrations {
+		s, ok := unwrapMigration(migration).(Superseder)
+		if !ok || s.Supersedes() == "" {
+			continue
+		}
+
+		until, err := versionWithOptionalName(s.Supersedes())
+		if err != nil {
+			return nil, err
+		}
+
+		recorded, untilApplied, partlyApplied := false, false, false
+		for _, v := range applied {
+			switch {
+			case v.String() == migration.Version().String():
+				recorded = true
+			case v.StringWithoutName() == until.StringWithoutName():
+				untilApplied = true
+			case v.GreaterThan(zero) && until.GreaterThan(&v):
+				partlyApplied = true
+			}
+		}
+
+		if recorded {
+			continue
+		}
+
+		if !untilApplied {
+			if partlyApplied {
+				return nil, fmt.Errorf("migrations superseded by '%s' are partly applied, apply them with the release that has them first", migration.Version())
+			}
+			continue
+		}
+
+		start := time.Now()
+		err = m.recordVersion(migration, 0)
+		err = m.recordHistory(migration.Version().String(), HistorySupersede, start, err)
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(m.output(), "Marked '%s' as applied, it supersedes applied migrations.\n", migration.Version())
+		applied = append(applied, migration.Version())
+	}
+
+	return applied, nil
+}
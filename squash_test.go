@@ -0,0 +1,130 @@
+package migo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/walkline/migo/sqlscanner"
+)
+
+func TestSquash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "squash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"1-create-a.up.sql":   "CREATE TABLE a (id INT);",
+		"1-create-a.down.sql": "DROP TABLE a;",
+		"2-create-b.sql":      "-- migo:up\nCREATE TABLE b (id INT);\n-- migo:down\nDROP TABLE b;\n",
+		"3-create-c.sql":      "-- migo:up\nCREATE TABLE c (id INT);\n-- migo:down\nDROP TABLE c;\n",
+	}
+	for name, content := range files {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+
+	scratch := &ConnectionMock{v: "0-null"}
+	m := NewMigrate(nil, NewSQLMigrationLoader(dir))
+	m.SetOutput(ioutil.Discard)
+	res, err := m.Squash(SquashOptions{
+		Until:   "2",
+		Scratch: scratch,
+		Dir:     dir,
+		Dump: func(c Connection) (string, error) {
+			return "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scratch.sqls) != 2 {
+		t.Errorf("expected 2 replayed statements, got %q", scratch.sqls)
+	}
+
+	if len(res.Superseded) != 2 || len(res.Archived) != 3 {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ArchiveDir, "2-create-b.sql")); err != nil {
+		t.Error(err)
+	}
+
+	existing := &HistoryConnectionMock{versions: []string{"1-create-a", "2-create-b", "3-create-c"}}
+	m = NewMigrate(existing, NewSQLMigrationLoader(dir))
+	m.SetOutput(ioutil.Discard)
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(existing.sqls) != 0 || existing.versions[3] != "2-squashed" || existing.history[0].Event != HistorySupersede {
+		t.Errorf("baseline should be recorded without running, got %q %v", existing.sqls, existing.versions)
+	}
+
+	fresh := &HistoryConnectionMock{}
+	m = NewMigrate(fresh, NewSQLMigrationLoader(dir))
+	m.SetOutput(ioutil.Discard)
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fresh.sqls) != 3 || fresh.versions[0] != "2-squashed" || fresh.versions[1] != "3-create-c" {
+		t.Errorf("unexpected fresh database %q %v", fresh.sqls, fresh.versions)
+	}
+
+	partial := &HistoryConnectionMock{versions: []string{"1-create-a"}}
+	m = NewMigrate(partial, NewSQLMigrationLoader(dir))
+	m.SetOutput(ioutil.Discard)
+	err = m.UpToLatest()
+	if err == nil {
+		t.Error("partly applied superseded migrations should fail")
+	}
+}
+
+func TestSquashSQLiteTrigger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "squash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "1-create-a.sql"), []byte("-- migo:up\nCREATE TABLE a (id INT, n INT);\n-- migo:down\nDROP TABLE a;\n"), 0644)
+
+	trigger := "CREATE TRIGGER a_n AFTER INSERT ON a\nBEGIN\n\tUPDATE a SET n = CASE WHEN new.id > 0 THEN 1 ELSE 0 END WHERE id = new.id;\n\tSELECT 1;\nEND;"
+	loader := NewSQLMigrationLoader(dir)
+	loader.SetDialect(sqlscanner.DialectSQLite)
+
+	m := NewMigrate(nil, loader)
+	m.SetOutput(ioutil.Discard)
+	_, err = m.Squash(SquashOptions{
+		Until:   "1",
+		Scratch: &ConnectionMock{v: "0-null"},
+		Dir:     dir,
+		Dump: func(c Connection) (string, error) {
+			return "CREATE TABLE a (id INT, n INT);\n\n" + trigger, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loader = NewSQLMigrationLoader(dir)
+	loader.SetDialect(sqlscanner.DialectSQLite)
+
+	fresh := &HistoryConnectionMock{}
+	m = NewMigrate(fresh, loader)
+	m.SetOutput(ioutil.Discard)
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fresh.sqls) != 2 || fresh.sqls[1] != trigger {
+		t.Errorf("trigger should be a single statement of baseline, got %q", fresh.sqls)
+	}
+}
@@ -0,0 +1,106 @@
+package migo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MigrationState is a state of migration reported by Status.
+type MigrationState string
+
+const (
+	// StateApplied is a migration applied to the database.
+	StateApplied MigrationState = "applied"
+	// StatePending is a migration that is not applied yet.
+	StatePending MigrationState = "pending"
+	// StateSkipped is a migration left out by TagFilter.
+	StateSkipped MigrationState = "skipped"
+)
+
+// MigrationStatus describes a loaded migration.
+type MigrationStatus struct {
+	Version string
+	State   MigrationState
+	Tags    []string
+	// Reason explains why migration is skipped.
+	Reason string
+}
+
+func (s MigrationStatus) String() string {
+	if s.Reason != "" {
+		return fmt.Sprintf("%s %s: %s", s.State, s.Version, s.Reason)
+	}
+
+	return fmt.Sprintf("%s %s", s.State, s.Version)
+}
+
+// Status returns loaded migrations in the order of versions with their
+// states, migrations left out by TagFilter are skipped unless they are
+// applied already.
+func (m *Migrate) Status() ([]MigrationStatus, error) {
+	err := m.loadMigrations()
+	if err != nil {
+		return nil, errors.New("can't load migrations " + err.Error())
+	}
+
+	verStrs, err := m.c.LoadVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedVers, err := StringsToVersions(verStrs)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[string]bool{}
+	for _, v := range appliedVers {
+		applied[v.StringWithoutName()] = true
+	}
+
+	type entry struct {
+		v      Version
+		status MigrationStatus
+	}
+
+	entries := []entry{}
+	for _, migration := range m.migrations {
+		v := migration.Version()
+		state := StatePending
+		if applied[v.StringWithoutName()] {
+			state = StateApplied
+		}
+
+		entries = append(entries, entry{v, MigrationStatus{
+			Version: v.String(),
+			State:   state,
+			Tags:    migrationTags(migration),
+		}})
+	}
+
+	for _, s := range m.skipped {
+		v, err := VersionFromString(s.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		status := MigrationStatus{Version: s.Version, State: StateSkipped, Tags: s.Tags, Reason: s.Reason}
+		if applied[v.StringWithoutName()] {
+			status.State, status.Reason = StateApplied, ""
+		}
+
+		entries = append(entries, entry{*v, status})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[j].v.GreaterThan(&entries[i].v)
+	})
+
+	statuses := make([]MigrationStatus, len(entries))
+	for i, e := range entries {
+		statuses[i] = e.status
+	}
+
+	return statuses, nil
+}
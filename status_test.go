@@ -0,0 +1,43 @@
+package migo
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &HistoryConnectionMock{versions: []string{"1-users", "3-postgis"}}
+	m, _ := newTaggedMigrate(dir, c, TagFilter{Exclude: []string{"dev"}})
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{}
+	for _, s := range statuses {
+		lines = append(lines, s.String())
+	}
+
+	expected := []string{
+		"applied 1-users",
+		"skipped 2-fixtures@dev: tag 'dev' is excluded",
+		"applied 3-postgis",
+		"pending 4-cache",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected statuses\n%s", strings.Join(lines, "\n"))
+	}
+
+	if len(c.sqls) != 0 {
+		t.Errorf("status should not run migrations, got %q", c.sqls)
+	}
+}
@@ -0,0 +1,172 @@
+package migo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TagSeparator separates tags in the name of migration,
+// e.g. `1-fixtures@dev@test.sql` is tagged `dev` and `test`.
+const TagSeparator = "@"
+
+const directiveTags = "tags"
+
+// Tagged is implemented by migrations that run only
+// in some environments, e.g. `dev` or `eu`. See TagFilter.
+type Tagged interface {
+	Tags() []string
+}
+
+// Tags returns tags of `-- migo:tags <tag>...` directives.
+func (m *SQLMigration) Tags() []string {
+	tags := []string{}
+	for _, d := range m.directives() {
+		if d.name == directiveTags {
+			tags = append(tags, d.args...)
+		}
+	}
+
+	return tags
+}
+
+// migrationTags returns lower cased tags of the name of migration
+// and of Tagged interface, without duplicates.
+func migrationTags(m Migration) []string {
+	tags := strings.Split(m.Version().Name, TagSeparator)[1:]
+	if t, ok := unwrapMigration(m).(Tagged); ok {
+		tags = append(tags, t.Tags()...)
+	}
+
+	seen := map[string]bool{}
+	result := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// TagFilter selects migrations by their tags. Migrations
+// without tags always run.
+type TagFilter struct {
+	// Include runs only tagged migrations that have one of the tags,
+	// all tagged migrations run when it is empty.
+	Include []string
+	// Exclude skips migrations that have one of the tags.
+	Exclude []string
+}
+
+// IsEmpty returns true when filter doesn't skip anything.
+func (f TagFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// skipReason returns why migration with the tags is skipped,
+// it is empty when migration runs.
+func (f TagFilter) skipReason(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	for _, tag := range tags {
+		if hasTag(f.Exclude, tag) {
+			return fmt.Sprintf("tag '%s' is excluded", tag)
+		}
+	}
+
+	if len(f.Include) == 0 {
+		return ""
+	}
+
+	for _, tag := range tags {
+		if hasTag(f.Include, tag) {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("tags %s are not included (%s)", strings.Join(tags, ", "), strings.Join(f.Include, ", "))
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SkippedMigration is a migration left out by TagFilter.
+type SkippedMigration struct {
+	Version string
+	Tags    []string
+	Reason  string
+}
+
+func (s SkippedMigration) String() string {
+	return fmt.Sprintf("%s: %s", s.Version, s.Reason)
+}
+
+// SetTagFilter sets which tagged migrations are loaded, migrations
+// left out by the filter are neither applied nor discarded.
+func (m *Migrate) SetTagFilter(f TagFilter) {
+	m.tagFilter = f
+}
+
+// Skipped returns migrations left out by TagFilter in the order of versions.
+func (m *Migrate) Skipped() ([]SkippedMigration, error) {
+	err := m.loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("can't load migrations: %w", err)
+	}
+
+	return m.skipped, nil
+}
+
+// filterTags drops migrations left out by TagFilter and remembers them.
+func (m *Migrate) filterTags(ms []Migration) []Migration {
+	if m.tagFilter.IsEmpty() {
+		return ms
+	}
+
+	result := []Migration{}
+	for _, migration := range m.sort(ms, true) {
+		tags := migrationTags(migration)
+		reason := m.tagFilter.skipReason(tags)
+		if reason == "" {
+			result = append(result, migration)
+			continue
+		}
+
+		m.skipped = append(m.skipped, SkippedMigration{
+			Version: migration.Version().String(),
+			Tags:    tags,
+			Reason:  reason,
+		})
+	}
+
+	return result
+}
+
+// printSkipped prints skipped migrations that are not applied.
+func (m *Migrate) printSkipped(applied []Version) {
+	isApplied := map[string]bool{}
+	for _, v := range applied {
+		isApplied[v.String()] = true
+	}
+
+	for _, s := range m.skipped {
+		if !isApplied[s.Version] {
+			fmt.Fprintf(m.output(), "Skipping '%s' migration: %s.\n", s.Version, s.Reason)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package migo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+type TaggedMigrationMock struct {
+	DependentMigrationMock
+	tags []string
+}
+
+func (m *TaggedMigrationMock) Tags() []string { return m.tags }
+
+func newTaggedMigrate(dir string, c Connection, f TagFilter) (*Migrate, *bytes.Buffer) {
+	ioutil.WriteFile(path.Join(dir, "1-users.sql"), []byte("-- migo:up\nCREATE TABLE users (id INT);\n-- migo:down\nDROP TABLE users;"), 0644)
+	ioutil.WriteFile(path.Join(dir, "2-fixtures@dev.sql"), []byte("-- migo:up\nINSERT INTO users VALUES (1);\n-- migo:down\nDELETE FROM users;"), 0644)
+	ioutil.WriteFile(path.Join(dir, "3-postgis.sql"), []byte("-- migo:tags dev Test\n-- migo:up\nCREATE EXTENSION postgis;\n-- migo:down\nDROP EXTENSION postgis;"), 0644)
+
+	out := &bytes.Buffer{}
+	m := NewMigrate(c, NewSQLMigrationLoader(dir))
+	m.SetOutput(out)
+	m.SetTagFilter(f)
+	m.Add(&TaggedMigrationMock{DependentMigrationMock{v: mustVersion("4-cache")}, []string{"eu"}})
+
+	return m, out
+}
+
+func TestTagFilterInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &HistoryConnectionMock{}
+	m, out := newTaggedMigrate(dir, c, TagFilter{Include: []string{"test"}})
+
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"CREATE TABLE users (id INT);",
+		"CREATE EXTENSION postgis;",
+	}
+	if strings.Join(c.sqls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected statements %q", c.sqls)
+	}
+
+	skipped := []string{
+		"Skipping '2-fixtures@dev' migration: tags dev are not included (test).",
+		"Skipping '4-cache' migration: tags eu are not included (test).",
+	}
+	for _, s := range skipped {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("output doesn't contain %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestTagFilterExclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &HistoryConnectionMock{}
+	m, _ := newTaggedMigrate(dir, c, TagFilter{Exclude: []string{"dev"}})
+
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"CREATE TABLE users (id INT);",
+		"UP 4-cache",
+	}
+	if strings.Join(c.sqls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected statements %q", c.sqls)
+	}
+
+	skipped, err := m.Skipped()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(skipped) != 2 || skipped[0].String() != "2-fixtures@dev: tag 'dev' is excluded" ||
+		strings.Join(skipped[1].Tags, ",") != "dev,test" {
+		t.Errorf("unexpected skipped migrations %v", skipped)
+	}
+}
+
+func TestTagFilterEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &HistoryConnectionMock{}
+	m, out := newTaggedMigrate(dir, c, TagFilter{})
+
+	err = m.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.sqls) != 4 || strings.Contains(out.String(), "Skipping") {
+		t.Errorf("every migration should run without filter %q", c.sqls)
+	}
+}
+
+func TestRunnerTagFilter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(path.Join(dir, "1-users.sql"), []byte("-- migo:up\nCREATE TABLE users (id INT);\n-- migo:down\nDROP TABLE users;"), 0644)
+	ioutil.WriteFile(path.Join(dir, "2-gdpr@eu.sql"), []byte("-- migo:up\nCREATE TABLE consents (id INT);\n-- migo:down\nDROP TABLE consents;"), 0644)
+
+	eu := &HistoryConnectionMock{}
+	us := &HistoryConnectionMock{}
+	r := NewRunner([]Target{
+		{Name: "eu", Connection: eu, TagFilter: &TagFilter{Include: []string{"eu"}}},
+		{Name: "us", Connection: us},
+	}, NewSQLMigrationLoader(dir))
+	r.SetTagFilter(TagFilter{Include: []string{"us"}})
+	r.SetOutput(ioutil.Discard)
+
+	report, err := r.UpToLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(eu.sqls) != 2 || len(us.sqls) != 1 {
+		t.Errorf("unexpected statements eu: %q, us: %q", eu.sqls, us.sqls)
+	}
+}
@@ -2,10 +2,11 @@ package migo
 
 import (
 	"bytes"
-	"html/template"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
+	"text/template"
 	"time"
 )
 
@@ -14,6 +15,7 @@ type TemplateType string
 const (
 	TemplateTypeSQLUp   TemplateType = "sql-up"
 	TemplateTypeSQLDown TemplateType = "sql-down"
+	TemplateTypeSQL     TemplateType = "sql"
 	TemplateTypeGo      TemplateType = "go"
 	TemplateTypeVersion TemplateType = "version"
 )
@@ -24,19 +26,29 @@ type Template struct {
 }
 
 func (t *Template) Build(v *Version) ([]byte, error) {
+	return t.BuildWithData(v, nil)
+}
+
+// BuildWithData builds template for the version, `data` is available
+// in template next to `version`, e.g. `{{.set}}`.
+func (t *Template) BuildWithData(v *Version, data map[string]interface{}) ([]byte, error) {
 	tmpl, err := template.ParseFiles(t.file)
 	if err != nil {
 		return nil, err
 	}
 
+	tmplData := map[string]interface{}{}
+	for k, v := range data {
+		tmplData[k] = v
+	}
+	tmplData["version"] = map[string]interface{}{
+		"name":    v.Name,
+		"verSafe": strings.Replace(v.StringWithoutName(), ".", "_", -1),
+		"ver":     v.StringWithoutName(),
+	}
+
 	buf := bytes.NewBufferString("")
-	err = tmpl.Execute(buf, map[string]interface{}{
-		"version": map[string]interface{}{
-			"name":    v.Name,
-			"verSafe": strings.Replace(v.StringWithoutName(), ".", "_", -1),
-			"ver":     v.StringWithoutName(),
-		},
-	})
+	err = tmpl.Execute(buf, tmplData)
 	if err != nil {
 		return nil, err
 	}
@@ -62,8 +74,107 @@ func (t *Template) BuildVersion() (string, error) {
 	return strings.Replace(buf.String(), "\n", "", -1), nil
 }
 
+// SQLFormat defines which files `migo new sql` creates.
+type SQLFormat string
+
+const (
+	// SQLFormatSplit is a pair of `.up.sql` and `.down.sql` files.
+	SQLFormatSplit SQLFormat = "split"
+	// SQLFormatSingle is a single `.sql` file with `-- migo:up` and `-- migo:down` sections.
+	SQLFormatSingle SQLFormat = "single"
+)
+
 type Templater struct {
 	templates map[TemplateType]Template
+	sqlFormat SQLFormat
+}
+
+// SetSQLFormat sets format of new sql migrations, SQLFormatSplit by default.
+func (t *Templater) SetSQLFormat(f SQLFormat) {
+	t.sqlFormat = f
+}
+
+// BuildSQL builds files of new sql migration in the format of the templater,
+// returns content keyed by file name. Statements of the migration can be
+// passed as `up` and `down` data, templates use them as `{{.up}}` and `{{.down}}`,
+// statements are appended to templates that don't use them.
+func (t *Templater) BuildSQL(v *Version, data map[string]interface{}) (map[string][]byte, error) {
+	sqlData := map[string]interface{}{"up": "", "down": ""}
+	for k, v := range data {
+		sqlData[k] = v
+	}
+	upSQL, _ := sqlData["up"].(string)
+	downSQL, _ := sqlData["down"].(string)
+
+	switch t.sqlFormat {
+	case SQLFormatSingle:
+		content, err := t.ContentForTemplateTypeWithData(TemplateTypeSQL, v, sqlData)
+		if err != nil {
+			return nil, err
+		}
+
+		if upSQL != "" && !bytes.Contains(content, []byte(upSQL)) {
+			content, err = insertSQLSections(content, upSQL, downSQL)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return map[string][]byte{v.String() + ".sql": content}, nil
+	case "", SQLFormatSplit:
+		up, err := t.ContentForTemplateTypeWithData(TemplateTypeSQLUp, v, sqlData)
+		if err != nil {
+			return nil, err
+		}
+
+		down, err := t.ContentForTemplateTypeWithData(TemplateTypeSQLDown, v, sqlData)
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Contains(up, []byte(upSQL)) {
+			up = append(up, upSQL...)
+		}
+
+		if !bytes.Contains(down, []byte(downSQL)) {
+			down = append(down, downSQL...)
+		}
+
+		return map[string][]byte{
+			v.String() + ".up.sql":   up,
+			v.String() + ".down.sql": down,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown sql format '%s'", t.sqlFormat)
+	}
+}
+
+// insertSQLSections puts statements at the end of up and down sections
+// of a single file migration.
+func insertSQLSections(content []byte, up, down string) ([]byte, error) {
+	lines := strings.SplitAfter(string(content), "\n")
+	downLine := -1
+	for i, line := range lines {
+		if strings.EqualFold(strings.TrimSpace(line), SQLDownMarker) {
+			downLine = i
+		}
+	}
+
+	if downLine == -1 {
+		return nil, fmt.Errorf("template has no '%s' section", SQLDownMarker)
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(strings.Join(lines[:downLine], ""))
+	b.WriteString(up)
+	b.WriteString("\n")
+	b.WriteString(strings.Join(lines[downLine:], ""))
+	if !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(down)
+
+	return []byte(b.String()), nil
 }
 
 func (t *Templater) LoadTemplates(path string) error {
@@ -91,6 +202,11 @@ func (t *Templater) ContentForTemplateType(tmplType TemplateType, v *Version) ([
 	return tmpl.Build(v)
 }
 
+func (t *Templater) ContentForTemplateTypeWithData(tmplType TemplateType, v *Version, data map[string]interface{}) ([]byte, error) {
+	tmpl := t.templates[tmplType]
+	return tmpl.BuildWithData(v, data)
+}
+
 func (t *Templater) TampleteWithType(tmplType TemplateType) *Template {
 	tmpl, found := t.templates[tmplType]
 	if !found {
@@ -122,6 +238,14 @@ func (t *Templater) loadSQLTemplates(path string) error {
 		}
 	}
 
+	singlePath := t.migoPath(path) + "/tmpl/sql/single.sql"
+	if _, err := os.Stat(singlePath); os.IsNotExist(err) {
+		err = ioutil.WriteFile(singlePath, []byte(SQLSingleDefaultTemplateData), 0755)
+		if err != nil {
+			return err
+		}
+	}
+
 	t.templates[TemplateTypeSQLUp] = Template{
 		file: upPath,
 		Type: TemplateTypeSQLUp,
@@ -132,6 +256,11 @@ func (t *Templater) loadSQLTemplates(path string) error {
 		Type: TemplateTypeSQLDown,
 	}
 
+	t.templates[TemplateTypeSQL] = Template{
+		file: singlePath,
+		Type: TemplateTypeSQL,
+	}
+
 	return nil
 }
 
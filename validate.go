@@ -0,0 +1,158 @@
+package migo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationKind is a kind of problem found by Validate.
+type ValidationKind string
+
+const (
+	// ValidationDuplicate is a version used by many migrations.
+	ValidationDuplicate ValidationKind = "duplicate"
+	// ValidationOrphan is an up or down file (or section) without a pair.
+	ValidationOrphan ValidationKind = "orphan"
+	// ValidationUnparsable is a file which name or content can't be parsed.
+	ValidationUnparsable ValidationKind = "unparsable"
+	// ValidationNonMonotonic is a timestamp of version that is lower than
+	// timestamp of a previous version or that is in the future.
+	ValidationNonMonotonic ValidationKind = "non-monotonic"
+)
+
+// ValidationProblem is a problem of migrations found by Validate.
+type ValidationProblem struct {
+	Kind ValidationKind
+	// Sources are files or go types of migrations.
+	Sources []string
+	Message string
+}
+
+func (p ValidationProblem) String() string {
+	return fmt.Sprintf("%s %s: %s", p.Kind, strings.Join(p.Sources, ", "), p.Message)
+}
+
+// ValidatingLoader is implemented by loaders that can report
+// problems of their sources, e.g. SQLMigrationsLoader. Validate returns
+// migrations of sources with valid versions, even of broken ones.
+type ValidatingLoader interface {
+	Validate() ([]Migration, []ValidationProblem, error)
+}
+
+// ValidationReport lists problems found by Validate.
+type ValidationReport struct {
+	Problems []ValidationProblem
+}
+
+// OK returns true when no problems are found.
+func (r *ValidationReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// String returns a problem per line.
+func (r *ValidationReport) String() string {
+	b := &strings.Builder{}
+	for _, p := range r.Problems {
+		fmt.Fprintln(b, p)
+	}
+
+	return b.String()
+}
+
+// Validate checks migrations of all loaders without touching the database:
+// versions used by many migrations, orphan up or down files, unparsable
+// names and timestamps of versions that don't grow with versions.
+func (m *Migrate) Validate() (*ValidationReport, error) {
+	report := &ValidationReport{}
+	migrations := []Migration{}
+
+	for _, loader := range m.loaders {
+		if v, ok := loader.(ValidatingLoader); ok {
+			validated, problems, err := v.Validate()
+			if err != nil {
+				return nil, err
+			}
+
+			report.Problems = append(report.Problems, problems...)
+			migrations = append(migrations, validated...)
+			continue
+		}
+
+		loaded, err := loader.Load()
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, loaded...)
+	}
+
+	// migrations replaced by squash baselines don't take their versions
+	migrations = m.sort(dropSuperseded(migrations), true)
+
+	byVersion := map[string][]string{}
+	versions := []string{}
+	for _, migration := range migrations {
+		v := migration.Version()
+		key := v.StringWithoutName()
+		if _, found := byVersion[key]; !found {
+			versions = append(versions, key)
+		}
+		byVersion[key] = append(byVersion[key], migrationSource(migration))
+	}
+
+	for _, v := range versions {
+		if sources := byVersion[v]; len(sources) > 1 {
+			sort.Strings(sources)
+			report.Problems = append(report.Problems, ValidationProblem{
+				Kind:    ValidationDuplicate,
+				Sources: sources,
+				Message: fmt.Sprintf("version %s is used by %d migrations", v, len(sources)),
+			})
+		}
+	}
+
+	now := time.Now().Unix()
+	var last *Version
+	for _, migration := range migrations {
+		v := migration.Version()
+		if v.timestamp == 0 {
+			continue
+		}
+
+		if v.timestamp > now {
+			report.Problems = append(report.Problems, ValidationProblem{
+				Kind:    ValidationNonMonotonic,
+				Sources: []string{migrationSource(migration)},
+				Message: fmt.Sprintf("timestamp of version %s is in the future", v.StringWithoutName()),
+			})
+		}
+
+		if last != nil && v.timestamp < last.timestamp {
+			report.Problems = append(report.Problems, ValidationProblem{
+				Kind:    ValidationNonMonotonic,
+				Sources: []string{migrationSource(migration)},
+				Message: fmt.Sprintf("version %s follows %s, but its timestamp is lower", v.StringWithoutName(), last.StringWithoutName()),
+			})
+			continue
+		}
+		last = &v
+	}
+
+	return report, nil
+}
+
+// migrationSource returns file of sql migration or type of go migration.
+func migrationSource(m Migration) string {
+	if s, ok := unwrapMigration(m).(*SQLMigration); ok {
+		switch {
+		case s.isSingleFile():
+			return s.Path
+		case s.UpPath != "":
+			return s.UpPath
+		}
+	}
+
+	return fmt.Sprintf("%T(%s)", unwrapMigration(m), m.Version())
+}
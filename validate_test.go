@@ -0,0 +1,114 @@
+package migo
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Mkdir(path.Join(dir, "billing"), 0755)
+	files := []string{
+		"1.0.0.100-create.up.sql",
+		"1.0.0.100-create.down.sql",
+		"billing/1.0.0.100-invoices.up.sql",
+		"billing/1.0.0.100-invoices.down.sql",
+		"1.0.0.200-orphan.down.sql",
+		"1.0.0.300-no-down.up.sql",
+		"create-users.up.sql",
+		"1.1.0.150-bump.up.sql",
+		"1.1.0.150-bump.down.sql",
+		"1.0.0.400-later.up.sql",
+		"1.0.0.400-later.down.sql",
+	}
+	for _, f := range files {
+		err = ioutil.WriteFile(path.Join(dir, f), []byte("SELECT 1;"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goLoader := &GoMigrationLoader{}
+	goLoader.Add(&DependentMigrationMock{v: mustVersion("1.0.0.400-go")})
+
+	m := NewMigrate(nil, NewSQLMigrationLoader(dir), goLoader)
+	report, err := m.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := map[ValidationKind]int{}
+	for _, p := range report.Problems {
+		kinds[p.Kind]++
+	}
+
+	if kinds[ValidationOrphan] != 2 || kinds[ValidationUnparsable] != 1 || kinds[ValidationDuplicate] != 2 || report.OK() {
+		t.Errorf("unexpected problems\n%s", report)
+	}
+
+	duplicate := "duplicate " + path.Join(dir, "1.0.0.100-create.up.sql") + ", " + path.Join(dir, "billing/1.0.0.100-invoices.up.sql")
+	if !strings.Contains(report.String(), duplicate) {
+		t.Errorf("duplicate next to orphan files should be reported\n%s", report)
+	}
+
+	os.Remove(path.Join(dir, "1.0.0.200-orphan.down.sql"))
+	os.Remove(path.Join(dir, "1.0.0.300-no-down.up.sql"))
+	os.Remove(path.Join(dir, "create-users.up.sql"))
+
+	report, err = NewMigrate(nil, NewSQLMigrationLoader(dir), goLoader).Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"duplicate " + path.Join(dir, "1.0.0.100-create.up.sql") + ", " + path.Join(dir, "billing/1.0.0.100-invoices.up.sql"),
+		"duplicate *migo.DependentMigrationMock(1.0.0.400-go), " + path.Join(dir, "1.0.0.400-later.up.sql"),
+		"non-monotonic " + path.Join(dir, "1.1.0.150-bump.up.sql"),
+	}
+
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("unexpected problems\n%s", report)
+	}
+
+	for i, e := range expected {
+		if !strings.Contains(lines[i], e) {
+			t.Errorf("expected '%s', got '%s'", e, lines[i])
+		}
+	}
+}
+
+func TestValidateSquashed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	until := mustVersion("1.0.0.200-squashed")
+	err = ioutil.WriteFile(path.Join(dir, "1.0.0.200-squashed.sql"), []byte(baselineContent(&until, "SELECT 1;")), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goLoader := &GoMigrationLoader{}
+	goLoader.Add(&DependentMigrationMock{v: mustVersion("1.0.0.100-go")})
+	goLoader.Add(&DependentMigrationMock{v: mustVersion("1.0.0.200-go")})
+
+	report, err := NewMigrate(nil, NewSQLMigrationLoader(dir), goLoader).Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.OK() {
+		t.Errorf("migrations superseded by baseline should not be duplicates\n%s", report)
+	}
+}
@@ -0,0 +1,93 @@
+package migo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// VariablesEnvPrefix is the prefix of environment variables
+// that are used as migration variables by VariablesFromEnv.
+const VariablesEnvPrefix = "MIGO_VAR_"
+
+var (
+	ErrUndefinedVariable    = errors.New("undefined variable")
+	ErrUnterminatedVariable = errors.New("unterminated variable")
+)
+
+// ExpandVariables replaces `${name}` placeholders with values from `vars`.
+// `$${` is an escape sequence for literal `${`.
+// Returns error if placeholder refers to undefined variable.
+func ExpandVariables(s string, vars map[string]string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+
+		b.WriteString(s[:i])
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w at '%s'", ErrUnterminatedVariable, s[i:])
+		}
+
+		name := strings.TrimSpace(s[i+2 : i+end])
+		value, found := vars[name]
+		if !found {
+			return "", fmt.Errorf("%w '%s'", ErrUndefinedVariable, name)
+		}
+
+		b.WriteString(value)
+		s = s[i+end+1:]
+	}
+
+	return b.String(), nil
+}
+
+// VariablesFromEnv returns variables from environment variables
+// with `MIGO_VAR_` prefix, e.g. `MIGO_VAR_schema=tenant1` sets `schema` variable.
+func VariablesFromEnv() map[string]string {
+	vars := map[string]string{}
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, VariablesEnvPrefix) {
+			continue
+		}
+
+		kv := strings.SplitN(strings.TrimPrefix(env, VariablesEnvPrefix), "=", 2)
+		if len(kv) == 2 && kv[0] != "" {
+			vars[kv[0]] = kv[1]
+		}
+	}
+
+	return vars
+}
+
+// MergeVariables merges variables maps into a new one,
+// values from the latter maps override values from the former.
+func MergeVariables(varsList ...map[string]string) map[string]string {
+	result := map[string]string{}
+	for _, vars := range varsList {
+		for k, v := range vars {
+			result[k] = v
+		}
+	}
+
+	return result
+}
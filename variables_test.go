@@ -0,0 +1,76 @@
+package migo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestExpandVariables(t *testing.T) {
+	vars := map[string]string{
+		"schema":   "tenant1",
+		"app_role": "app",
+	}
+
+	for _, testCase := range []struct {
+		str      string
+		expected string
+		err      error
+	}{
+		{"SELECT 1;", "SELECT 1;", nil},
+		{"CREATE TABLE ${schema}.users ();", "CREATE TABLE tenant1.users ();", nil},
+		{"GRANT ALL ON ${schema}.users TO ${ app_role };", "GRANT ALL ON tenant1.users TO app;", nil},
+		{"SELECT '$${schema}';", "SELECT '${schema}';", nil},
+		{"SELECT '$$${schema}';", "SELECT '$${schema}';", nil},
+		{"SELECT $$ $1 $$;", "SELECT $$ $1 $$;", nil},
+		{"SELECT '${unknown}';", "", ErrUndefinedVariable},
+		{"SELECT '${schema';", "", ErrUnterminatedVariable},
+	} {
+		result, err := ExpandVariables(testCase.str, vars)
+		if !errors.Is(err, testCase.err) {
+			t.Errorf("expected error: %v; actual: %v", testCase.err, err)
+		}
+
+		if result != testCase.expected {
+			t.Errorf("expected: %v; actual: %v", testCase.expected, result)
+		}
+	}
+}
+
+func TestVariablesFromEnv(t *testing.T) {
+	os.Setenv("MIGO_VAR_schema", "tenant2")
+	defer os.Unsetenv("MIGO_VAR_schema")
+
+	vars := MergeVariables(map[string]string{"schema": "tenant1", "role": "app"}, VariablesFromEnv())
+	if vars["schema"] != "tenant2" || vars["role"] != "app" {
+		t.Error("unexpected variables", vars)
+	}
+}
+
+func TestUpSQLMigrationWithUndefinedVariable(t *testing.T) {
+	upFile, err := ioutil.TempFile("", "up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(upFile.Name())
+
+	upFile.WriteString("CREATE SCHEMA ${schema}; GRANT USAGE ON SCHEMA ${schema} TO ${role};")
+	upFile.Seek(0, os.SEEK_SET)
+
+	c := &ConnectionMock{}
+	m := &SQLMigration{
+		UpFile:    upFile,
+		Variables: map[string]string{"schema": "tenant1"},
+	}
+	m.SetConnection(c)
+
+	err = m.Up()
+	if !errors.Is(err, ErrUndefinedVariable) {
+		t.Error("expected undefined variable error, have", err)
+	}
+
+	if len(c.sqls) != 1 || c.sqls[0] != "CREATE SCHEMA tenant1;" {
+		t.Error("unexpected sqls", c.sqls)
+	}
+}